@@ -0,0 +1,298 @@
+// Package feedsd implements a standard command line launcher of feedsd daemon, so main() of the daemon only needs to
+// pass its feeds to Main().
+package feedsd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"github.com/samber/mo"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/browser"
+	"github.com/KonishchevDmitry/feedsd/pkg/selector"
+	"github.com/KonishchevDmitry/feedsd/pkg/server"
+	"github.com/KonishchevDmitry/feedsd/pkg/tracing"
+)
+
+const envPrefix = "FEEDSD_"
+
+const builtinHub = "builtin"
+
+type flags struct {
+	feedsAddr   string
+	metricsAddr string
+	develMode   bool
+	logLevel    string
+	stateDir    string
+	config      string
+	publicURL   string
+	webSubHub   string
+	otlpURL     string
+
+	browser               bool
+	browserRemote         string
+	browserHeadful        bool
+	browserPersistentData string
+
+	scrape mo.Option[scrapeFlags]
+}
+
+// Main runs the daemon and exits the process when it stops
+func Main(opts ...Option) {
+	if err := Run(context.Background(), os.Args[0], os.Args[1:], opts...); err != nil {
+		var reported *reportedError
+		if !errors.As(err, &reported) {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %s.\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// Run parses the command line arguments and runs the daemon until it gets SIGINT or SIGTERM.
+//
+// "scrape" subcommand (scrape [flags] NAME [PARAM=VALUE...]) scrapes the specified feed once and prints the result
+// without starting the daemon.
+func Run(ctx context.Context, name string, args []string, opts ...Option) error {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	flags, err := parseFlags(name, args)
+	if err != nil {
+		return err
+	}
+
+	logger, err := newLogger(name, flags)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logger.Sync()
+	}()
+	ctx = logging.WithLogger(ctx, logger)
+
+	ctx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	if flags.otlpURL != "" {
+		stopTracing, err := tracing.Configure(ctx, tracing.Endpoint(flags.otlpURL))
+		if err != nil {
+			return fmt.Errorf("failed to configure tracing: %w", err)
+		}
+		defer stopTracing()
+	}
+
+	if browserOptions, ok := getBrowserOptions(flags, options); ok {
+		var stopBrowser func()
+		ctx, stopBrowser, err = browser.Configure(ctx, browserOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to configure the browser: %w", err)
+		}
+		defer stopBrowser()
+	}
+
+	serverOptions := options.serverOptions
+	if flags.stateDir != "" {
+		serverOptions = append(serverOptions, server.StateDir(flags.stateDir))
+	}
+	if webSubOption, ok, err := getWebSubOption(flags); err != nil {
+		return err
+	} else if ok {
+		serverOptions = append(serverOptions, webSubOption)
+	}
+	s := server.New(serverOptions...)
+
+	feeds := options.feeds
+	if flags.config != "" {
+		configFeeds, err := selector.Load(flags.config)
+		if err != nil {
+			return err
+		}
+		feeds = append(feeds, configFeeds...)
+	}
+
+	for _, feed := range feeds {
+		if err := s.Register(feed); err != nil {
+			return err
+		}
+	}
+
+	for _, setup := range options.setup {
+		if err := setup(s); err != nil {
+			return err
+		}
+	}
+
+	if scrapeFlags, ok := flags.scrape.Get(); ok {
+		return scrape(ctx, s, scrapeFlags)
+	}
+
+	return s.Serve(ctx, flags.feedsAddr, flags.metricsAddr, flags.develMode)
+}
+
+func parseFlags(name string, args []string) (flags, error) {
+	var (
+		flags       flags
+		scrapeFlags scrapeFlags
+	)
+
+	scrapeMode := len(args) != 0 && args[0] == scrapeCommand
+	if scrapeMode {
+		name += " " + scrapeCommand
+		args = args[1:]
+	}
+
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	if scrapeMode {
+		scrapeFlags.register(flagSet)
+	}
+	flagSet.StringVar(&flags.feedsAddr, "feeds-addr", ":8080", "address to serve feeds on")
+	flagSet.StringVar(&flags.metricsAddr, "metrics-addr", ":9090", "address to serve metrics and admin API on")
+	flagSet.BoolVar(&flags.develMode, "devel", false, "development mode: feeds are scraped only on demand")
+	flagSet.StringVar(&flags.logLevel, "log-level", "",
+		"log level (debug in development mode, warn for scrape command and info otherwise by default)")
+	flagSet.StringVar(&flags.stateDir, "state-dir", "", "directory to persist the daemon state in")
+	flagSet.StringVar(&flags.config, "config", "", "YAML file with declarative CSS selector feeds")
+	flagSet.StringVar(&flags.publicURL, "public-url", "", "public URL of the feeds server (required for WebSub)")
+	flagSet.StringVar(&flags.webSubHub, "websub-hub", "",
+		fmt.Sprintf("URL of WebSub hub to publish the feeds via or %q to use the built-in hub", builtinHub))
+	flagSet.StringVar(&flags.otlpURL, "otlp-endpoint", "",
+		"OTLP/HTTP collector URL to export traces to (http://localhost:4318 for example)")
+
+	flagSet.BoolVar(&flags.browser, "browser", false, "configure the browser for feeds that use browser emulation")
+	flagSet.StringVar(&flags.browserRemote, "browser-remote", "", "host:port of a remote browser to use")
+	flagSet.BoolVar(&flags.browserHeadful, "browser-headful", false, "run the browser in headful mode")
+	flagSet.StringVar(&flags.browserPersistentData, "browser-persistent-data", "",
+		"persist browser data in a directory named after the specified daemon name")
+
+	// Environment variables (FEEDSD_FEEDS_ADDR, etc.) override the defaults, but not the command line arguments
+	var envErr error
+	flagSet.VisitAll(func(f *flag.Flag) {
+		envName := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if value, ok := os.LookupEnv(envName); ok && envErr == nil {
+			if err := f.Value.Set(value); err != nil {
+				envErr = fmt.Errorf("invalid %s environment variable value: %w", envName, err)
+			}
+		}
+	})
+	if envErr != nil {
+		return flags, envErr
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return flags, &reportedError{err: err}
+	}
+
+	if scrapeMode {
+		if err := scrapeFlags.parseArgs(flagSet.Args()); err != nil {
+			return flags, err
+		}
+		flags.scrape = mo.Some(scrapeFlags)
+	} else if flagSet.NArg() != 0 {
+		return flags, fmt.Errorf("unexpected arguments: %s", strings.Join(flagSet.Args(), " "))
+	}
+
+	return flags, nil
+}
+
+// The error has been already reported along with the usage by the flag set
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+func newLogger(name string, flags flags) (*zap.SugaredLogger, error) {
+	level := zapcore.InfoLevel
+	if flags.develMode {
+		level = zapcore.DebugLevel
+	} else if flags.scrape.IsPresent() {
+		// Informational messages are written to stdout and would be mixed with the scrape result
+		level = zapcore.WarnLevel
+	}
+
+	if flags.logLevel != "" {
+		var err error
+		if level, err = zapcore.ParseLevel(flags.logLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	daemon := !flags.develMode && flags.scrape.IsAbsent()
+
+	return logging.Configure(logging.Config{
+		Daemon:           daemon,
+		SyslogIdentifier: filepath.Base(name),
+		Level:            level,
+		ShowLevel:        !daemon,
+		ShowTime:         !daemon,
+	})
+}
+
+func getBrowserOptions(flags flags, options options) ([]browser.Option, bool) {
+	var browserOptions []browser.Option
+
+	if flags.browserRemote != "" {
+		browserOptions = append(browserOptions, browser.Remote(flags.browserRemote))
+	}
+	if flags.browserHeadful {
+		browserOptions = append(browserOptions, browser.Headful())
+	}
+	if flags.browserPersistentData != "" {
+		browserOptions = append(browserOptions, browser.PersistentData(flags.browserPersistentData))
+	}
+
+	return browserOptions, options.browser || flags.browser || len(browserOptions) != 0
+}
+
+func getWebSubOption(flags flags) (server.Option, bool, error) {
+	if flags.webSubHub == "" {
+		return nil, false, nil
+	} else if flags.publicURL == "" {
+		return nil, false, errors.New("WebSub requires the public URL of the feeds server to be specified")
+	}
+
+	publicURL, err := parseURL(flags.publicURL)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid public URL: %w", err)
+	}
+
+	if flags.webSubHub == builtinHub {
+		return server.WebSubHub(publicURL), true, nil
+	}
+
+	hubURL, err := parseURL(flags.webSubHub)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid WebSub hub URL: %w", err)
+	}
+
+	return server.WebSub(publicURL, hubURL), true, nil
+}
+
+func parseURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	} else if !parsed.IsAbs() || parsed.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", value)
+	}
+	return parsed, nil
+}
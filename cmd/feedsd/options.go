@@ -0,0 +1,44 @@
+package feedsd
+
+import (
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+	"github.com/KonishchevDmitry/feedsd/pkg/server"
+)
+
+type options struct {
+	feeds         []feed.Feed
+	setup         []func(s *server.Server) error
+	serverOptions []server.Option
+	browser       bool
+}
+
+type Option func(o *options)
+
+// Feeds registers the specified background feeds
+func Feeds(feeds ...feed.Feed) Option {
+	return func(o *options) {
+		o.feeds = append(o.feeds, feeds...)
+	}
+}
+
+// Setup registers a function which is called on the server before it starts (for example, to register parametrized
+// feeds)
+func Setup(setup func(s *server.Server) error) Option {
+	return func(o *options) {
+		o.setup = append(o.setup, setup)
+	}
+}
+
+// ServerOptions passes the specified options to the server. Options set via command line flags take precedence.
+func ServerOptions(opts ...server.Option) Option {
+	return func(o *options) {
+		o.serverOptions = append(o.serverOptions, opts...)
+	}
+}
+
+// Browser configures the browser on start, which is required by feeds that use browser emulation
+func Browser() Option {
+	return func(o *options) {
+		o.browser = true
+	}
+}
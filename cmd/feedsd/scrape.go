@@ -0,0 +1,113 @@
+package feedsd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/fetch"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/server"
+)
+
+const (
+	scrapeCommand = "scrape"
+
+	formatRSS   = "rss"
+	formatTable = "table"
+)
+
+type scrapeFlags struct {
+	format  string
+	dumpDir string
+
+	name   string
+	params url.Values
+}
+
+func (f *scrapeFlags) register(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&f.format, "format", formatTable, "output format: rss or table")
+	flagSet.StringVar(&f.dumpDir, "dump-dir", "", "directory to dump all fetched documents to")
+}
+
+func (f *scrapeFlags) parseArgs(args []string) error {
+	if f.format != formatRSS && f.format != formatTable {
+		return fmt.Errorf("invalid output format: %q", f.format)
+	}
+
+	if len(args) == 0 {
+		return errors.New("feed name is not specified")
+	}
+	f.name = args[0]
+
+	f.params = make(url.Values)
+	for _, arg := range args[1:] {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid feed parameter: %q", arg)
+		}
+		f.params.Add(name, value)
+	}
+
+	return nil
+}
+
+func scrape(ctx context.Context, s *server.Server, flags scrapeFlags) error {
+	if flags.dumpDir != "" {
+		if err := os.MkdirAll(flags.dumpDir, 0700); err != nil {
+			return err
+		}
+		ctx = fetch.WithDumpDir(ctx, flags.dumpDir)
+	}
+
+	result, err := s.Scrape(ctx, flags.name, flags.params)
+	if err != nil {
+		return err
+	} else if result.HTTPStatus != http.StatusOK {
+		return fmt.Errorf("failed to scrape %s feed: %w", flags.name, result.Error)
+	}
+
+	switch flags.format {
+	case formatRSS:
+		_, err = os.Stdout.Write(result.Data)
+	case formatTable:
+		feed, ok := result.Feed()
+		if !ok {
+			return errors.New("the scrape result has no feed")
+		}
+		err = printTable(os.Stdout, feed)
+	}
+
+	return err
+}
+
+func printTable(writer io.Writer, feed *rss.Feed) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(table, "%s (%d items)\n\n", feed.Title, len(feed.Items))
+	_, _ = fmt.Fprintln(table, "DATE\tTITLE\tLINK")
+
+	for _, item := range feed.Items {
+		date := "-"
+		if !item.Date.IsZero() {
+			date = item.Date.Local().Format(time.DateTime)
+		}
+
+		title := item.Title
+		if runes := []rune(title); len(runes) > 80 {
+			title = string(runes[:79]) + "…"
+		}
+
+		_, _ = fmt.Fprintf(table, "%s\t%s\t%s\n", date, title, item.Link)
+	}
+
+	return table.Flush()
+}
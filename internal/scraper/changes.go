@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"context"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+// Compares the scraped feed with the previous successfully scraped one and reports the added and removed items
+func (s *BackgroundScraper) trackChanges(ctx context.Context, result ScrapeResult) {
+	feed, ok := result.Feed()
+	if !ok {
+		return
+	}
+	s.backgroundMetrics.items().Set(float64(len(feed.Items)))
+
+	lock := s.lock.Lock()
+	lastSuccess, ok := s.lastSuccess.Get()
+	lock.Unlock()
+
+	// There is nothing to compare with on the first scrape, so we can't tell which items are new
+	if !ok {
+		return
+	}
+	previous, ok := lastSuccess.Feed()
+	if !ok {
+		return
+	}
+
+	name := s.feed.Name()
+	added, removed := diffItems(previous, feed)
+
+	for _, item := range added {
+		logging.L(ctx).Infof("%s feed: new item: %s (%s).", name, item.Title, item.GUID.ID)
+	}
+	for _, item := range removed {
+		logging.L(ctx).Infof("%s feed: removed item: %s (%s).", name, item.Title, item.GUID.ID)
+	}
+
+	if len(added) == 0 {
+		return
+	}
+
+	s.backgroundMetrics.newItems().Add(float64(len(added)))
+	s.backgroundMetrics.lastNewItem().Set(float64(result.Time.Unix()))
+
+	lock = s.lock.Lock()
+	s.lastNewItem = mo.Some(result.Time)
+	lock.Unlock()
+}
+
+// Returns items which are present only in the current and only in the previous feed. Items are identified by GUID, so
+// the feeds must be normalized.
+func diffItems(previous *rss.Feed, current *rss.Feed) ([]*rss.Item, []*rss.Item) {
+	previousIDs := itemIDs(previous)
+	currentIDs := itemIDs(current)
+
+	var added, removed []*rss.Item
+	for _, item := range current.Items {
+		if id := item.GUID.ID; id != "" && !previousIDs[id] {
+			added = append(added, item)
+		}
+	}
+	for _, item := range previous.Items {
+		if id := item.GUID.ID; id != "" && !currentIDs[id] {
+			removed = append(removed, item)
+		}
+	}
+
+	return added, removed
+}
+
+func itemIDs(feed *rss.Feed) map[string]bool {
+	ids := make(map[string]bool, len(feed.Items))
+	for _, item := range feed.Items {
+		ids[item.GUID.ID] = true
+	}
+	return ids
+}
@@ -0,0 +1,122 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingIdentity = "identity"
+	encodingGzip     = "gzip"
+	encodingZstd     = "zstd"
+	encodingBrotli   = "br"
+)
+
+// Supported encodings in order of our preference
+var encodings = []string{encodingZstd, encodingBrotli, encodingGzip}
+
+var (
+	zstdDefaultEncoder, _ = zstd.NewWriter(nil)
+	zstdBestEncoder, _    = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+)
+
+type encodedData struct {
+	etag string
+	data []byte
+}
+
+// Background feeds are compressed once per scrape and the compressed data is cached in ScrapeResult, so we can afford
+// high compression levels for them
+func precompress(data []byte, etag string) map[string]encodedData {
+	variants := make(map[string]encodedData, len(encodings))
+	for _, encoding := range encodings {
+		if encoded, err := compress(encoding, data, etag, true); err == nil {
+			variants[encoding] = encoded
+		}
+	}
+	return variants
+}
+
+func compress(encoding string, data []byte, etag string, best bool) (encodedData, error) {
+	var buffer bytes.Buffer
+
+	switch encoding {
+	case encodingZstd:
+		encoder := zstdDefaultEncoder
+		if best {
+			encoder = zstdBestEncoder
+		}
+		buffer.Write(encoder.EncodeAll(data, nil))
+
+	case encodingBrotli:
+		level := brotli.DefaultCompression
+		if best {
+			level = 9
+		}
+		if err := compressWith(brotli.NewWriterLevel(&buffer, level), data); err != nil {
+			return encodedData{}, err
+		}
+
+	case encodingGzip:
+		level := gzip.DefaultCompression
+		if best {
+			level = gzip.BestCompression
+		}
+		writer, err := gzip.NewWriterLevel(&buffer, level)
+		if err != nil {
+			return encodedData{}, err
+		}
+		if err := compressWith(writer, data); err != nil {
+			return encodedData{}, err
+		}
+
+	default:
+		return encodedData{}, fmt.Errorf("unsupported encoding: %q", encoding)
+	}
+
+	return encodedData{
+		// Each representation must have its own strong ETag
+		etag: strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`,
+		data: buffer.Bytes(),
+	}, nil
+}
+
+func compressWith(writer io.WriteCloser, data []byte) error {
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Selects the best supported encoding according to Accept-Encoding header
+func negotiateEncoding(acceptEncoding string) string {
+	var (
+		best        = encodingIdentity
+		bestQuality float64
+		qualities   = parseQualityValues(acceptEncoding)
+	)
+
+	wildcard, ok := qualities["*"]
+	if !ok {
+		wildcard = -1
+	}
+
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = max(wildcard, 0)
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	t.Parallel()
+
+	for acceptEncoding, expected := range map[string]string{
+		"":                           encodingIdentity,
+		"identity":                   encodingIdentity,
+		"gzip":                       encodingGzip,
+		"gzip, deflate, br":          encodingBrotli,
+		"gzip, deflate, br, zstd":    encodingZstd,
+		"gzip;q=1.0, br;q=0.5":       encodingGzip,
+		"zstd;q=0, gzip":             encodingGzip,
+		"*":                          encodingZstd,
+		"*;q=0.1, zstd;q=0":          encodingBrotli,
+		"GZIP; q=0.8, unknown":       encodingGzip,
+		"gzip;q=0, br;q=0, zstd;q=x": encodingIdentity,
+	} {
+		require.Equal(t, expected, negotiateEncoding(acceptEncoding), acceptEncoding)
+	}
+}
+
+func TestCompressedResult(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("<item>Some data</item>\n"), 1000)
+
+	decoders := map[string]func(reader io.Reader) (io.Reader, error){
+		encodingGzip: func(reader io.Reader) (io.Reader, error) {
+			return gzip.NewReader(reader)
+		},
+		encodingBrotli: func(reader io.Reader) (io.Reader, error) {
+			return brotli.NewReader(reader), nil
+		},
+		encodingZstd: func(reader io.Reader) (io.Reader, error) {
+			return zstd.NewReader(reader)
+		},
+	}
+
+	// Results of background feeds are precompressed and the others are compressed on the fly
+	for _, precompressed := range []bool{false, true} {
+		result := makeScrapeResult(http.StatusOK, "application/rss+xml", data)
+		if precompressed {
+			result.precompress()
+			require.Len(t, result.encodings, len(encodings))
+		} else {
+			require.Empty(t, result.encodings)
+		}
+
+		etags := map[string]struct{}{result.ETag: {}}
+
+		for _, encoding := range encodings {
+			request := httptest.NewRequest(http.MethodGet, "/test.rss", nil)
+			request.Header.Set("Accept-Encoding", encoding)
+
+			recorder := httptest.NewRecorder()
+			result.Write(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, encoding, recorder.Header().Get("Content-Encoding"))
+			require.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+			require.Less(t, recorder.Body.Len(), len(data))
+
+			etag := recorder.Header().Get("ETag")
+			require.NotContains(t, etags, etag)
+			etags[etag] = struct{}{}
+
+			reader, err := decoders[encoding](recorder.Body)
+			require.NoError(t, err)
+
+			decoded, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			require.Equal(t, data, decoded)
+		}
+	}
+}
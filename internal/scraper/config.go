@@ -0,0 +1,41 @@
+package scraper
+
+import (
+	"time"
+
+	"github.com/samber/mo"
+)
+
+type Config struct {
+	// Maximum number of concurrent scrapes of all background and parametrized feeds
+	Concurrency int
+
+	// A directory where scraped feeds are persisted to survive daemon restarts
+	StateDir mo.Option[string]
+
+	// For how long the last successfully scraped feed is served when the following scrapes fail
+	StaleMaxAge time.Duration
+
+	// If set, the feed which is failing for longer than the specified duration is served as the last successfully
+	// scraped feed with an additional item describing the failure
+	FailureNotice mo.Option[time.Duration]
+
+	// Retry policy for temporary scrape failures of background feeds
+	Retry RetryPolicy
+
+	// If set, background feeds are published via WebSub
+	WebSub mo.Option[WebSubConfig]
+
+	// For how long parametrized feed scrape results are cached. Zero value disables the caching.
+	ParametrizedCacheTTL time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Concurrency: 4,
+		StaleMaxAge: 24 * time.Hour,
+		Retry:       DefaultRetryPolicy(),
+
+		ParametrizedCacheTTL: 5 * time.Minute,
+	}
+}
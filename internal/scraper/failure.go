@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+// Generates the last successfully scraped feed with an additional item which notifies the reader about the outage
+func makeFailureNotice(
+	name string, lastSuccess ScrapeResult, lastError ScrapeResult, failingSince time.Time, links mo.Option[feedLinks],
+) (ScrapeResult, error) {
+	lastFeed, ok := lastSuccess.Feed()
+	if !ok {
+		return ScrapeResult{}, errors.New("the last successfully scraped feed is not available")
+	}
+
+	item := &rss.Item{
+		Title: fmt.Sprintf("%s feed is failing", name),
+		// The GUID stays the same during the whole outage, so readers show only one notice per outage
+		GUID: rss.MakeGUID(fmt.Sprintf("feedsd:failure:%s:%d", name, failingSince.Unix()), false),
+		Link: lastFeed.Link,
+		Description: fmt.Sprintf(
+			"The feed can't be updated since %s due to %s. The last successful update was %s ago.",
+			failingSince.UTC().Format(time.RFC1123), describeFailure(lastError),
+			lastError.Time.Sub(lastSuccess.Time).Round(time.Minute)),
+		Date: rss.Date{Time: failingSince},
+	}
+
+	feed := *lastFeed
+	feed.Items = append([]*rss.Item{item}, lastFeed.Items...)
+
+	result, err := makeFeedResult(&feed, links)
+	if err != nil {
+		return ScrapeResult{}, err
+	}
+	result.Time = lastError.Time
+
+	return result, nil
+}
+
+func describeFailure(result ScrapeResult) string {
+	switch result.HTTPStatus {
+	case http.StatusGatewayTimeout:
+		return "temporary unavailability of the source"
+	case http.StatusInternalServerError:
+		return "an internal error of the feed generator"
+	default:
+		return "a scraping error"
+	}
+}
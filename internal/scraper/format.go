@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// Supported formats in order of our preference
+var Formats = []Format{FormatRSS, FormatAtom, FormatJSON}
+
+// FeedPath returns the path the feed is served at in the specified format
+func FeedPath(name string, format Format) string {
+	return fmt.Sprintf("/%s.%s", name, format)
+}
+
+var formatMediaTypes = map[Format][]string{
+	FormatRSS:  {"application/rss+xml", "application/xml", "text/xml"},
+	FormatAtom: {"application/atom+xml"},
+	FormatJSON: {"application/feed+json", "application/json"},
+}
+
+// NegotiateFormat selects the feed format according to Accept header. Only explicitly requested media types are taken
+// into account, so RSS is returned for clients which accept anything.
+func NegotiateFormat(accept string) Format {
+	qualities := parseQualityValues(accept)
+
+	best, bestQuality := FormatRSS, 0.0
+	for _, format := range Formats {
+		for _, mediaType := range formatMediaTypes[format] {
+			if quality, ok := qualities[mediaType]; ok && quality > bestQuality {
+				best, bestQuality = format, quality
+			}
+		}
+	}
+
+	return best
+}
+
+// Parses Accept-like header values into lowercase value -> quality map. Values with invalid quality are skipped.
+func parseQualityValues(header string) map[string]float64 {
+	qualities := make(map[string]float64)
+
+	for value := range strings.SplitSeq(header, ",") {
+		value, params, _ := strings.Cut(value, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		quality := 1.0
+		if name, qvalue, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(qvalue), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		qualities[value] = quality
+	}
+
+	return qualities
+}
@@ -0,0 +1,24 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	t.Parallel()
+
+	for accept, expected := range map[string]Format{
+		"":                                       FormatRSS,
+		"*/*":                                    FormatRSS,
+		"application/atom+xml":                   FormatAtom,
+		"application/feed+json":                  FormatJSON,
+		"application/json, */*;q=0.1":            FormatJSON,
+		"application/json, application/atom+xml": FormatAtom,
+		"application/rss+xml;q=0.5, application/atom+xml;q=0.9": FormatAtom,
+		"Application/Feed+JSON; q=0.8, text/html":               FormatJSON,
+	} {
+		require.Equal(t, expected, NegotiateFormat(accept), accept)
+	}
+}
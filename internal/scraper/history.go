@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/internal/storage"
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+// Accumulates items of feeds which sources show only a few latest items
+type itemHistory struct {
+	name   string
+	config feed.History
+
+	lock    sync.Mutex
+	storage mo.Option[*storage.Storage]
+	loaded  bool
+	items   []*historyItem
+}
+
+type historyState struct {
+	Items []*historyItem `json:"items"`
+}
+
+type historyItem struct {
+	FirstSeen time.Time `json:"first_seen"`
+	Item      *rss.Item `json:"item"`
+}
+
+func (i *historyItem) time() time.Time {
+	if date := i.Item.Date; !date.IsZero() {
+		return date.Time
+	}
+	return i.FirstSeen
+}
+
+func newItemHistory(name string, config feed.History) *itemHistory {
+	return &itemHistory{
+		name:   name,
+		config: config,
+	}
+}
+
+func (h *itemHistory) setStorage(storage *storage.Storage) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.storage = mo.Some(storage)
+}
+
+// Merges the scraped items with the previously seen ones. Items are identified by GUID, so the feed must be normalized.
+func (h *itemHistory) merge(ctx context.Context, feed *rss.Feed) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.load(ctx)
+
+	now := time.Now()
+	known := make(map[string]*historyItem, len(h.items))
+	for _, item := range h.items {
+		known[item.Item.GUID.ID] = item
+	}
+
+	var current []*historyItem
+	scraped := make(map[string]struct{}, len(feed.Items))
+
+	for _, item := range feed.Items {
+		id := item.GUID.ID
+		if id == "" {
+			continue
+		} else if _, ok := scraped[id]; ok {
+			continue
+		}
+		scraped[id] = struct{}{}
+
+		firstSeen := now
+		if previous, ok := known[id]; ok {
+			firstSeen = previous.FirstSeen
+		}
+
+		current = append(current, &historyItem{
+			FirstSeen: firstSeen,
+			Item:      item,
+		})
+	}
+
+	// Retention limits are applied only to the items which are missing in the source now
+	var previous []*historyItem
+	for _, item := range h.items {
+		if _, ok := scraped[item.Item.GUID.ID]; ok {
+			continue
+		} else if maxAge := h.config.MaxAge; maxAge != 0 && now.Sub(item.time()) > maxAge {
+			continue
+		}
+		previous = append(previous, item)
+	}
+
+	slices.SortStableFunc(previous, func(a, b *historyItem) int {
+		return b.time().Compare(a.time())
+	})
+	if maxItems := h.config.MaxItems; maxItems != 0 {
+		previous = previous[:min(len(previous), max(0, maxItems-len(current)))]
+	}
+
+	h.items = append(current, previous...)
+	for _, item := range previous {
+		feed.Items = append(feed.Items, item.Item)
+	}
+
+	h.save(ctx)
+}
+
+func (h *itemHistory) load(ctx context.Context) {
+	if h.loaded {
+		return
+	}
+	h.loaded = true
+
+	storage, ok := h.storage.Get()
+	if !ok {
+		return
+	}
+
+	var state historyState
+	if _, err := storage.Load(h.name, &state); err != nil {
+		logging.L(ctx).Errorf("Failed to load %s feed item history: %s.", h.name, err)
+		return
+	}
+
+	h.items = slices.DeleteFunc(state.Items, func(item *historyItem) bool {
+		return item == nil || item.Item == nil || item.Item.GUID.ID == ""
+	})
+}
+
+func (h *itemHistory) save(ctx context.Context) {
+	storage, ok := h.storage.Get()
+	if !ok {
+		return
+	}
+
+	if err := storage.Save(h.name, &historyState{Items: h.items}); err != nil {
+		logging.L(ctx).Errorf("Failed to save %s feed item history: %s.", h.name, err)
+	}
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/internal/storage"
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+func TestItemHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	now := time.Now()
+
+	historyStorage, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	newHistory := func() *itemHistory {
+		history := newItemHistory("test", feed.History{
+			MaxItems: 4,
+			MaxAge:   10 * 24 * time.Hour,
+		})
+		history.setStorage(historyStorage)
+		return history
+	}
+
+	scrape := func(history *itemHistory, ids ...int) []string {
+		feed := rss.NewFeed("Test feed", url.MustParse("https://example.com/"))
+		for _, id := range ids {
+			feed.AddItem(
+				now.Add(-time.Duration(id)*24*time.Hour), fmt.Sprintf("Item %d", id),
+				url.MustParse(fmt.Sprintf("https://example.com/%d", id)), "")
+		}
+		feed.Normalize()
+
+		history.merge(ctx, feed)
+
+		var titles []string
+		for _, item := range feed.Items {
+			titles = append(titles, item.Title)
+		}
+		return titles
+	}
+
+	history := newHistory()
+	require.Equal(t, []string{"Item 1", "Item 2"}, scrape(history, 1, 2))
+	require.Equal(t, []string{"Item 2", "Item 3", "Item 1"}, scrape(history, 2, 3))
+
+	// Check persistence and the age limit
+	history = newHistory()
+	require.Equal(t, []string{"Item 11", "Item 1", "Item 2", "Item 3"}, scrape(history, 11))
+	require.Equal(t, []string{"Item 2", "Item 1", "Item 3"}, scrape(history, 2))
+
+	// Check the count limit
+	require.Equal(t, []string{"Item 4", "Item 5", "Item 6", "Item 1"}, scrape(history, 4, 5, 6))
+	require.Equal(t, []string{"Item 7", "Item 8", "Item 9", "Item 10", "Item 11"}, scrape(history, 7, 8, 9, 10, 11))
+}
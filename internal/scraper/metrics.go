@@ -14,9 +14,17 @@ const (
 type metrics struct {
 	startTime      *prometheus.GaugeVec
 	feedTime       *prometheus.GaugeVec
+	errorTime      *prometheus.GaugeVec
+	retries        *prometheus.CounterVec
+	items          *prometheus.GaugeVec
+	newItems       *prometheus.CounterVec
+	lastNewItem    *prometheus.GaugeVec
+	cacheRequests  *prometheus.CounterVec
 	feedStatus     *prometheus.CounterVec
 	fetchDuration  *prometheus.HistogramVec
 	scrapeDuration *prometheus.HistogramVec
+	queueWait      *prometheus.HistogramVec
+	queueDepth     prometheus.Gauge
 }
 
 func makeMetrics() metrics {
@@ -31,10 +39,40 @@ func makeMetrics() metrics {
 			Help: "Feed time",
 		}, []string{"name"}),
 
+		errorTime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "feeds_error_time",
+			Help: "Last feed scrape error time",
+		}, []string{"name"}),
+
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feeds_retries_total",
+			Help: "Feed scrape retry attempts after temporary failures",
+		}, []string{"name"}),
+
+		items: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "feeds_items",
+			Help: "Number of items in the last successfully scraped feed",
+		}, []string{"name"}),
+
+		newItems: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feeds_new_items_total",
+			Help: "Items which have appeared in the feed since the previous scrape",
+		}, []string{"name"}),
+
+		lastNewItem: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "feeds_last_new_item_time",
+			Help: "Time when a new item has appeared in the feed last time",
+		}, []string{"name"}),
+
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feeds_cache_requests_total",
+			Help: "Parametrized feed result cache requests",
+		}, []string{"name", "result"}),
+
 		feedStatus: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "feeds_status_total",
-			Help: "Feed generation status",
-		}, []string{"name", "status"}),
+			Help: "Feed generation status and error category",
+		}, []string{"name", "status", "category"}),
 
 		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Name:    "feeds_fetch_duration",
@@ -47,26 +85,46 @@ func makeMetrics() metrics {
 			Help:    "Feed scrape duration",
 			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 900, 1200},
 		}, []string{"name"}),
+
+		queueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "feeds_queue_wait_duration",
+			Help:    "Time spent by scrapes waiting for a free worker",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1200},
+		}, []string{"name"}),
+
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "feeds_queue_depth",
+			Help: "Number of scrapes waiting for a free worker",
+		}),
 	}
 }
 
 type baseObservers struct {
+	status         *statusTracker
 	feedStatus     *prometheus.CounterVec
 	fetchDuration  prometheus.Observer
 	scrapeDuration prometheus.Observer
+	queueWait      prometheus.Observer
 }
 
-func (m *metrics) baseObservers(name string) *baseObservers {
+func (m *metrics) baseObservers(name string, parametrized bool) *baseObservers {
 	return &baseObservers{
+		status:         newStatusTracker(name, parametrized),
 		feedStatus:     m.feedStatus.MustCurryWith(prometheus.Labels{"name": name}),
 		fetchDuration:  m.fetchDuration.WithLabelValues(name),
 		scrapeDuration: m.scrapeDuration.WithLabelValues(name),
+		queueWait:      m.queueWait.WithLabelValues(name),
 	}
 }
 
 type backgroundObservers struct {
-	startTime func() prometheus.Gauge
-	feedTime  func() prometheus.Gauge
+	startTime   func() prometheus.Gauge
+	feedTime    func() prometheus.Gauge
+	errorTime   func() prometheus.Gauge
+	retries     func() prometheus.Counter
+	items       func() prometheus.Gauge
+	newItems    func() prometheus.Counter
+	lastNewItem func() prometheus.Gauge
 }
 
 func (m *metrics) backgroundObservers(name string) *backgroundObservers {
@@ -77,23 +135,97 @@ func (m *metrics) backgroundObservers(name string) *backgroundObservers {
 		feedTime: func() prometheus.Gauge {
 			return m.feedTime.WithLabelValues(name)
 		},
+		errorTime: func() prometheus.Gauge {
+			return m.errorTime.WithLabelValues(name)
+		},
+		retries: func() prometheus.Counter {
+			return m.retries.WithLabelValues(name)
+		},
+		items: func() prometheus.Gauge {
+			return m.items.WithLabelValues(name)
+		},
+		newItems: func() prometheus.Counter {
+			return m.newItems.WithLabelValues(name)
+		},
+		lastNewItem: func() prometheus.Gauge {
+			return m.lastNewItem.WithLabelValues(name)
+		},
+	}
+}
+
+type cacheObservers struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	coalesced prometheus.Counter
+}
+
+func (m *metrics) cacheObservers(name string) *cacheObservers {
+	return &cacheObservers{
+		hits:      m.cacheRequests.WithLabelValues(name, "hit"),
+		misses:    m.cacheRequests.WithLabelValues(name, "miss"),
+		coalesced: m.cacheRequests.WithLabelValues(name, "coalesced"),
 	}
 }
 
+// Deletes all metrics of the specified feed
+func (m *metrics) delete(name string) {
+	m.deleteBackground(name)
+	m.deleteCache(name)
+
+	labels := prometheus.Labels{"name": name}
+	m.feedStatus.DeletePartialMatch(labels)
+	m.fetchDuration.DeletePartialMatch(labels)
+	m.scrapeDuration.DeletePartialMatch(labels)
+	m.queueWait.DeletePartialMatch(labels)
+}
+
+// Deletes metrics which are specific to background feeds
+func (m *metrics) deleteBackground(name string) {
+	labels := prometheus.Labels{"name": name}
+	m.startTime.DeletePartialMatch(labels)
+	m.feedTime.DeletePartialMatch(labels)
+	m.errorTime.DeletePartialMatch(labels)
+	m.retries.DeletePartialMatch(labels)
+	m.items.DeletePartialMatch(labels)
+	m.newItems.DeletePartialMatch(labels)
+	m.lastNewItem.DeletePartialMatch(labels)
+}
+
+// Deletes metrics which are specific to parametrized feeds
+func (m *metrics) deleteCache(name string) {
+	m.cacheRequests.DeletePartialMatch(prometheus.Labels{"name": name})
+}
+
 var _ prometheus.Collector = &metrics{}
 
 func (m *metrics) Describe(descs chan<- *prometheus.Desc) {
 	m.startTime.Describe(descs)
 	m.feedTime.Describe(descs)
+	m.errorTime.Describe(descs)
+	m.retries.Describe(descs)
+	m.items.Describe(descs)
+	m.newItems.Describe(descs)
+	m.lastNewItem.Describe(descs)
+	m.cacheRequests.Describe(descs)
 	m.feedStatus.Describe(descs)
 	m.fetchDuration.Describe(descs)
 	m.scrapeDuration.Describe(descs)
+	m.queueWait.Describe(descs)
+	m.queueDepth.Describe(descs)
 }
 
 func (m *metrics) Collect(metrics chan<- prometheus.Metric) {
 	m.startTime.Collect(metrics)
 	m.feedTime.Collect(metrics)
+	m.errorTime.Collect(metrics)
+	m.retries.Collect(metrics)
+	m.items.Collect(metrics)
+	m.newItems.Collect(metrics)
+	m.lastNewItem.Collect(metrics)
+	m.cacheRequests.Collect(metrics)
 	m.feedStatus.Collect(metrics)
 	m.fetchDuration.Collect(metrics)
 	m.scrapeDuration.Collect(metrics)
+	m.queueWait.Collect(metrics)
+	m.queueDepth.Collect(metrics)
 }
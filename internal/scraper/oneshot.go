@@ -0,0 +1,16 @@
+package scraper
+
+import (
+	"context"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+)
+
+// ScrapeOnce scrapes the feed in the same way as the daemon does, but without scheduling, caching, persistence and
+// exported metrics. It's intended for debugging.
+func ScrapeOnce(ctx context.Context, feed feed.Feed) ScrapeResult {
+	metrics := makeMetrics()
+	pool := newWorkerPool(1, metrics.queueDepth)
+	scraper := makeBaseScraper(feed, pool, metrics.baseObservers(feed.Name(), false))
+	return scraper.scrape(ctx)
+}
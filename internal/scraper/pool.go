@@ -0,0 +1,40 @@
+package scraper
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/semaphore"
+)
+
+// Limits the number of concurrent scrapes of all background and parametrized feeds
+type workerPool struct {
+	semaphore  *semaphore.Weighted
+	queueDepth prometheus.Gauge
+}
+
+func newWorkerPool(concurrency int, queueDepth prometheus.Gauge) *workerPool {
+	return &workerPool{
+		semaphore:  semaphore.NewWeighted(int64(max(1, concurrency))),
+		queueDepth: queueDepth,
+	}
+}
+
+func (p *workerPool) acquire(ctx context.Context, queueWait prometheus.Observer) (func(), error) {
+	startTime := time.Now()
+
+	if !p.semaphore.TryAcquire(1) {
+		p.queueDepth.Inc()
+		err := p.semaphore.Acquire(ctx, 1)
+		p.queueDepth.Dec()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	queueWait.Observe(time.Since(startTime).Seconds())
+	return func() {
+		p.semaphore.Release(1)
+	}, nil
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	queueDepth := prometheus.NewGauge(prometheus.GaugeOpts{})
+	queueWait := &testObserver{}
+	pool := newWorkerPool(2, queueDepth)
+
+	acquire := func(ctx context.Context) <-chan func() {
+		acquired := make(chan func(), 1)
+		go func() {
+			release, err := pool.acquire(ctx, queueWait)
+			if err != nil {
+				release = nil
+			}
+			acquired <- release
+		}()
+		return acquired
+	}
+
+	first, second := <-acquire(ctx), <-acquire(ctx)
+	require.Zero(t, promtestutil.ToFloat64(queueDepth))
+
+	// The pool is full, so the third scrape must wait for a free worker
+	third := acquire(ctx)
+	require.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(queueDepth) == 1
+	}, time.Second, time.Millisecond)
+	require.Empty(t, third)
+
+	// The waiting may be cancelled
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancelled := acquire(cancelCtx)
+	require.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(queueDepth) == 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.Nil(t, <-cancelled)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(queueDepth))
+
+	time.Sleep(10 * time.Millisecond)
+	first()
+	release := <-third
+	require.NotNil(t, release)
+	require.Zero(t, promtestutil.ToFloat64(queueDepth))
+
+	// Cancelled waits aren't observed
+	waits := queueWait.get()
+	require.Len(t, waits, 3)
+	require.GreaterOrEqual(t, waits[2], 0.01)
+
+	second()
+	release()
+}
+
+func TestParametrizedScrapeCancellation(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testParametrizedFeed{
+		release: make(chan struct{}),
+	}
+	close(feed.release)
+
+	config := DefaultConfig()
+	config.Concurrency = 1
+	registry := NewRegistry(config)
+
+	scraper, err := AddParametrized(registry, feed)
+	require.NoError(t, err)
+
+	// Occupy the only worker
+	release, err := registry.pool.acquire(ctx, &testObserver{})
+	require.NoError(t, err)
+
+	requestCtx, cancel := context.WithCancel(ctx)
+	result := make(chan ScrapeResult, 1)
+	go func() {
+		result <- scraper.Scrape(requestCtx, testParams{value: "value"})
+	}()
+
+	require.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(registry.queueDepth) == 1
+	}, time.Second, time.Millisecond)
+
+	// The abandoned request must leave the queue and must not be scraped
+	cancel()
+	require.Equal(t, http.StatusGatewayTimeout, (<-result).HTTPStatus)
+	require.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(registry.queueDepth) == 0
+	}, time.Second, time.Millisecond)
+
+	release()
+	require.Zero(t, feed.calls.Load())
+}
+
+func TestParametrizedScrapeFirstRequestCancellation(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testParametrizedFeed{
+		release: make(chan struct{}),
+	}
+	close(feed.release)
+
+	config := DefaultConfig()
+	config.Concurrency = 1
+	registry := NewRegistry(config)
+
+	scraper, err := AddParametrized(registry, feed)
+	require.NoError(t, err)
+
+	// Occupy the only worker
+	release, err := registry.pool.acquire(ctx, &testObserver{})
+	require.NoError(t, err)
+
+	params := testParams{value: "value"}
+	firstCtx, cancel := context.WithCancel(ctx)
+	first, second := make(chan ScrapeResult, 1), make(chan ScrapeResult, 1)
+	go func() {
+		first <- scraper.Scrape(firstCtx, params)
+	}()
+	require.Eventually(t, func() bool {
+		return getFlightWaiters(scraper, params) == 1
+	}, time.Second, time.Millisecond)
+	go func() {
+		second <- scraper.Scrape(ctx, params)
+	}()
+	require.Eventually(t, func() bool {
+		return getFlightWaiters(scraper, params) == 2
+	}, time.Second, time.Millisecond)
+
+	// The request which has started the scrape goes away, but the scrape is still needed by the second one
+	cancel()
+	require.Equal(t, http.StatusGatewayTimeout, (<-first).HTTPStatus)
+
+	release()
+	require.Equal(t, http.StatusOK, (<-second).HTTPStatus)
+	require.Equal(t, int64(1), feed.calls.Load())
+}
+
+type testObserver struct {
+	lock   sync.Mutex
+	values []float64
+}
+
+func (o *testObserver) Observe(value float64) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.values = append(o.values, value)
+}
+
+func (o *testObserver) get() []float64 {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.values
+}
@@ -2,68 +2,352 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/internal/storage"
 	"github.com/KonishchevDmitry/feedsd/pkg/feed"
 )
 
+var (
+	ErrUnknownFeed       = errors.New("the feed is not registered")
+	ErrNotBackgroundFeed = errors.New("the feed is not a background one")
+)
+
 type Registry struct {
-	scrapers           map[string]struct{}
-	backgroundScrapers []*BackgroundScraper
+	config Config
+	pool   *workerPool
 	metrics
+
+	// Serializes feed registration and removal
+	updateLock sync.Mutex
+
+	lock     sync.Mutex
+	scrapers map[string]*registeredFeed
+	running  mo.Option[registryRuntime]
+}
+
+type registeredFeed struct {
+	status     *statusTracker
+	background mo.Option[*BackgroundScraper]
+}
+
+// Everything needed to start background scrapers of feeds which are added after the registry start
+type registryRuntime struct {
+	ctx       context.Context //nolint:containedctx
+	state     mo.Option[*stateStorage]
+	develMode bool
 }
 
-func NewRegistry() *Registry {
+func NewRegistry(config Config) *Registry {
+	metrics := makeMetrics()
+
 	return &Registry{
-		scrapers: make(map[string]struct{}),
-		metrics:  makeMetrics(),
+		config:   config,
+		pool:     newWorkerPool(config.Concurrency, metrics.queueDepth),
+		metrics:  metrics,
+		scrapers: make(map[string]*registeredFeed),
 	}
 }
 
+// Add registers a new background feed. If the registry is already started, the feed scraper is started immediately.
 func (r *Registry) Add(feed feed.Feed) (*BackgroundScraper, error) {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
 	name := feed.Name()
-	if err := r.add(name); err != nil {
+	if err := r.checkName(name); err != nil {
 		return nil, err
 	}
 
-	scraper := newBackgroundScraper(feed, r.metrics.baseObservers(name), r.metrics.backgroundObservers(name))
-	r.backgroundScrapers = append(r.backgroundScrapers, scraper)
+	baseObservers := r.metrics.baseObservers(name, false)
+	scraper := newBackgroundScraper(feed, r.config, r.pool, baseObservers, r.metrics.backgroundObservers(name))
+
+	r.lock.Lock()
+	r.scrapers[name] = &registeredFeed{
+		status:     baseObservers.status,
+		background: mo.Some(scraper),
+	}
+	running := r.running
+	r.lock.Unlock()
+
+	if running, ok := running.Get(); ok {
+		scraper.start(running.ctx, running.state, running.develMode)
+	}
 
 	return scraper, nil
 }
 
+// Replace registers the background feed or replaces the already registered feed with the same name. The new scraper
+// takes over the results of the old one, so the feed stays available. The old scraper keeps running until the returned
+// function is called, which allows the caller to switch to the new scraper first.
+func (r *Registry) Replace(feed feed.Feed) (*BackgroundScraper, func(ctx context.Context)) {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	name := feed.Name()
+	old := r.getFeed(name)
+
+	baseObservers := r.metrics.baseObservers(name, false)
+	scraper := newBackgroundScraper(feed, r.config, r.pool, baseObservers, r.metrics.backgroundObservers(name))
+	if old, ok := old.Get(); ok {
+		baseObservers.status.inherit(old.status)
+		if oldScraper, ok := old.background.Get(); ok {
+			scraper.inherit(oldScraper)
+		}
+	}
+
+	r.lock.Lock()
+	r.scrapers[name] = &registeredFeed{
+		status:     baseObservers.status,
+		background: mo.Some(scraper),
+	}
+	running := r.running
+	r.lock.Unlock()
+
+	if running, ok := running.Get(); ok {
+		scraper.start(running.ctx, running.state, running.develMode)
+	}
+
+	return scraper, r.retire(name, old, true)
+}
+
 func AddParametrized[P feed.Params](r *Registry, feed feed.ParametrizedFeed[P]) (*SimpleParametrizedScraper[P], error) {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
 	name := feed.Name()
-	if err := r.add(name); err != nil {
+	if err := r.checkName(name); err != nil {
 		return nil, err
 	}
 
-	scraper := newSimpleParametrizedScraper(feed, r.metrics.baseObservers(name))
+	baseObservers := r.metrics.baseObservers(name, true)
+	scraper := newSimpleParametrizedScraper(feed, r.config, r.pool, baseObservers, r.metrics.cacheObservers(name))
+
+	r.lock.Lock()
+	r.scrapers[name] = &registeredFeed{
+		status: baseObservers.status,
+	}
+	r.lock.Unlock()
+
 	return scraper, nil
 }
 
-func (r *Registry) add(name string) error {
+// ReplaceParametrized registers the parametrized feed or replaces the already registered feed with the same name. The
+// returned function must be called after switching to the new scraper to stop the old one.
+func ReplaceParametrized[P feed.Params](
+	r *Registry, feed feed.ParametrizedFeed[P],
+) (*SimpleParametrizedScraper[P], func(ctx context.Context)) {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	name := feed.Name()
+	old := r.getFeed(name)
+
+	baseObservers := r.metrics.baseObservers(name, true)
+	scraper := newSimpleParametrizedScraper(feed, r.config, r.pool, baseObservers, r.metrics.cacheObservers(name))
+	if old, ok := old.Get(); ok {
+		baseObservers.status.inherit(old.status)
+	}
+
+	r.lock.Lock()
+	r.scrapers[name] = &registeredFeed{
+		status: baseObservers.status,
+	}
+	r.lock.Unlock()
+
+	return scraper, r.retire(name, old, false)
+}
+
+// Returns a function which stops the replaced feed scraper and deletes its metrics which aren't used by the new one
+func (r *Registry) retire(name string, old mo.Option[*registeredFeed], background bool) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		old, ok := old.Get()
+		if !ok {
+			return
+		}
+
+		if oldScraper, ok := old.background.Get(); ok {
+			oldScraper.stop(ctx)
+			if !background {
+				r.metrics.deleteBackground(name)
+			}
+		} else if background {
+			r.metrics.deleteCache(name)
+		}
+	}
+}
+
+// Remove unregisters the feed, stops its scraper and deletes its metrics
+func (r *Registry) Remove(ctx context.Context, name string) error {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	r.lock.Lock()
+	feed, ok := r.scrapers[name]
+	delete(r.scrapers, name)
+	r.lock.Unlock()
+
+	if !ok {
+		return ErrUnknownFeed
+	}
+
+	// Stop the scraper first to not get the metrics recreated by it
+	if scraper, ok := feed.background.Get(); ok {
+		scraper.stop(ctx)
+	}
+	r.metrics.delete(name)
+
+	return nil
+}
+
+func (r *Registry) getFeed(name string) mo.Option[*registeredFeed] {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	feed, ok := r.scrapers[name]
+	return mo.TupleToOption(feed, ok)
+}
+
+func (r *Registry) checkName(name string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if _, ok := r.scrapers[name]; ok {
 		return fmt.Errorf("%q feed is already registered", name)
 	}
-	r.scrapers[name] = struct{}{}
 	return nil
 }
 
-func (r *Registry) Start(ctx context.Context, develMode bool) {
-	for _, scraper := range r.backgroundScrapers {
-		scraper.start(ctx, develMode)
+func (r *Registry) Statuses() []FeedStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	statuses := make([]FeedStatus, 0, len(r.scrapers))
+	for _, feed := range r.scrapers {
+		statuses = append(statuses, feed.status.get())
+	}
+
+	slices.SortFunc(statuses, func(a, b FeedStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return statuses
+}
+
+// Pending returns names of background feeds which don't have any scrape result yet
+func (r *Registry) Pending() []string {
+	var names []string
+	for name, scraper := range r.getBackgroundScrapers() {
+		if !scraper.HasResult() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (r *Registry) Refresh(name string) error {
+	r.lock.Lock()
+	feed, ok := r.scrapers[name]
+	r.lock.Unlock()
+
+	if !ok {
+		return ErrUnknownFeed
 	}
+
+	scraper, ok := feed.background.Get()
+	if !ok {
+		return ErrNotBackgroundFeed
+	}
+
+	scraper.Refresh()
+	return nil
+}
+
+func (r *Registry) Start(ctx context.Context, develMode bool) error {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	var state mo.Option[*stateStorage]
+	if stateDir, ok := r.config.StateDir.Get(); ok {
+		storage, err := openStateStorage(stateDir)
+		if err != nil {
+			return err
+		}
+		state = mo.Some(storage)
+	}
+
+	r.lock.Lock()
+	r.running = mo.Some(registryRuntime{
+		ctx:       ctx,
+		state:     state,
+		develMode: develMode,
+	})
+	r.lock.Unlock()
+
+	for _, scraper := range r.getBackgroundScrapers() {
+		scraper.start(ctx, state, develMode)
+	}
+
+	return nil
 }
 
 func (r *Registry) Stop(ctx context.Context) {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	r.lock.Lock()
+	r.running = mo.None[registryRuntime]()
+	r.lock.Unlock()
+
 	var waitGroup sync.WaitGroup
 	defer waitGroup.Wait()
 
-	for _, scraper := range r.backgroundScrapers {
+	for _, scraper := range r.getBackgroundScrapers() {
 		waitGroup.Go(func() {
 			scraper.stop(ctx)
 		})
 	}
 }
+
+func (r *Registry) getBackgroundScrapers() map[string]*BackgroundScraper {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	scrapers := make(map[string]*BackgroundScraper)
+	for name, feed := range r.scrapers {
+		if scraper, ok := feed.background.Get(); ok {
+			scrapers[name] = scraper
+		}
+	}
+	return scrapers
+}
+
+type stateStorage struct {
+	snapshots *storage.Storage
+	history   *storage.Storage
+}
+
+func openStateStorage(path string) (*stateStorage, error) {
+	snapshots, err := storage.New(filepath.Join(path, "snapshots"))
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := storage.New(filepath.Join(path, "history"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &stateStorage{
+		snapshots: snapshots,
+		history:   history,
+	}, nil
+}
@@ -0,0 +1,171 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+type ScrapeResult struct {
+	HTTPStatus  int
+	ContentType string
+	Data        []byte
+
+	Time  time.Time
+	Error error
+
+	// Strong ETag of the data and the time when it has been changed last time
+	ETag         string
+	LastModified time.Time
+
+	// Compressed variants of the data (only for background feeds)
+	encodings map[string]encodedData
+
+	// The same feed rendered in alternative formats
+	formats map[Format]ScrapeResult
+
+	// The feed the data is generated from
+	feed *rss.Feed
+
+	// Set when the result is the last successfully scraped feed which is served instead of the current error
+	Stale bool
+}
+
+func makeScrapeResult(status int, contentType string, data []byte) ScrapeResult {
+	now := time.Now()
+	hash := sha256.Sum256(data)
+
+	return ScrapeResult{
+		HTTPStatus:  status,
+		ContentType: contentType,
+		Data:        data,
+
+		Time: now,
+
+		ETag:         fmt.Sprintf("%q", hex.EncodeToString(hash[:16])),
+		LastModified: now,
+	}
+}
+
+func makeErrorResult(status int, err error) ScrapeResult {
+	result := makeScrapeResult(status, "text/plain", []byte("Failed to generate the RSS feed"))
+	result.Error = err
+	return result
+}
+
+// Generates a successful result with the feed rendered in all supported formats
+func makeFeedResult(feed *rss.Feed, links mo.Option[feedLinks]) (ScrapeResult, error) {
+	feed = withLinks(feed, links, FormatRSS)
+
+	data, err := rss.Generate(feed)
+	if err != nil {
+		return ScrapeResult{}, fmt.Errorf("failed to render the RSS feed: %w", err)
+	}
+
+	result := makeScrapeResult(http.StatusOK, rss.ContentType, data)
+	if err := result.setFeed(feed, links); err != nil {
+		return ScrapeResult{}, err
+	}
+
+	return result, nil
+}
+
+// Attaches the feed to the result and renders it in the alternative formats
+func (r *ScrapeResult) setFeed(feed *rss.Feed, links mo.Option[feedLinks]) error {
+	atom, err := rss.GenerateAtom(withLinks(feed, links, FormatAtom), r.LastModified)
+	if err != nil {
+		return fmt.Errorf("failed to render the Atom feed: %w", err)
+	}
+
+	json, err := rss.GenerateJSONFeed(withLinks(feed, links, FormatJSON))
+	if err != nil {
+		return fmt.Errorf("failed to render the JSON feed: %w", err)
+	}
+
+	r.feed = feed
+	r.formats = map[Format]ScrapeResult{
+		FormatAtom: makeScrapeResult(http.StatusOK, rss.AtomContentType, atom),
+		FormatJSON: makeScrapeResult(http.StatusOK, rss.JSONFeedContentType, json),
+	}
+
+	return nil
+}
+
+// Compresses the result in all supported formats and encodings, so they don't have to be compressed on each request
+func (r *ScrapeResult) precompress() {
+	if r.HTTPStatus != http.StatusOK {
+		return
+	}
+
+	r.encodings = precompress(r.Data, r.ETag)
+	for format, result := range r.formats {
+		result.encodings = precompress(result.Data, result.ETag)
+		r.formats[format] = result
+	}
+}
+
+// Format returns the result in the specified format. Error results are returned as is.
+func (r *ScrapeResult) Format(format Format) ScrapeResult {
+	if format == FormatRSS || r.HTTPStatus != http.StatusOK {
+		return *r
+	}
+
+	result, ok := r.formats[format]
+	if !ok {
+		return makeErrorResult(http.StatusInternalServerError, fmt.Errorf("the feed is not available in %s format", format))
+	}
+
+	// All formats are generated from the same feed, so they share its metadata
+	result.Time = r.Time
+	result.LastModified = r.LastModified
+	result.Stale = r.Stale
+
+	return result
+}
+
+// Feed returns the feed the result has been generated from. It's available only for successful results.
+func (r *ScrapeResult) Feed() (*rss.Feed, bool) {
+	return r.feed, r.feed != nil
+}
+
+func (r *ScrapeResult) Write(writer http.ResponseWriter, request *http.Request) {
+	header := writer.Header()
+	header.Set("Content-Type", r.ContentType)
+	if r.Stale {
+		header.Set("Age", strconv.Itoa(int(time.Since(r.Time).Seconds())))
+	}
+
+	if r.HTTPStatus != http.StatusOK {
+		writer.WriteHeader(r.HTTPStatus)
+		_, _ = writer.Write(r.Data)
+		return
+	}
+
+	etag, data := r.ETag, r.Data
+	header.Add("Vary", "Accept-Encoding")
+	if encoding := negotiateEncoding(request.Header.Get("Accept-Encoding")); encoding != encodingIdentity {
+		encoded, ok := r.encodings[encoding]
+		if !ok {
+			// Results which aren't precompressed are compressed on the fly with the default level
+			var err error
+			encoded, err = compress(encoding, data, etag, false)
+			ok = err == nil
+		}
+		if ok {
+			header.Set("Content-Encoding", encoding)
+			etag, data = encoded.etag, encoded.data
+		}
+	}
+
+	// ServeContent handles conditional and HEAD requests for us
+	header.Set("ETag", etag)
+	http.ServeContent(writer, request, "", r.LastModified, bytes.NewReader(data))
+}
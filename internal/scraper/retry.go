@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+type RetryPolicy struct {
+	// Maximum number of retries after a temporary scrape failure. Zero value disables retries.
+	MaxAttempts int
+
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  5,
+		InitialDelay: time.Minute,
+		MaxDelay:     15 * time.Minute,
+	}
+}
+
+// Returns a delay before the specified (zero-based) retry attempt
+func (p RetryPolicy) delay(attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || p.InitialDelay <= 0 {
+		return 0, false
+	}
+
+	delay := p.InitialDelay
+	for range attempt {
+		if delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	delay = min(delay, max(p.MaxDelay, p.InitialDelay))
+
+	// Use "equal jitter" to not retry all failed feeds at the same time when the failure is caused by some common reason
+	// like network outage.
+	return delay/2 + rand.N(delay/2+1), true
+}
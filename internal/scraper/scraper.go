@@ -3,6 +3,7 @@ package scraper
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/rand/v2"
 	"net/http"
@@ -12,124 +13,409 @@ import (
 	"time"
 
 	logging "github.com/KonishchevDmitry/go-easy-logging"
+	cache "github.com/go-pkgz/expirable-cache/v3"
 	"github.com/samber/mo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/KonishchevDmitry/feedsd/internal/storage"
 	"github.com/KonishchevDmitry/feedsd/internal/util"
 	"github.com/KonishchevDmitry/feedsd/pkg/feed"
 	"github.com/KonishchevDmitry/feedsd/pkg/fetch"
 	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/schedule"
 )
 
-const scrapePeriod = time.Hour
+var defaultSchedule = schedule.Every(time.Hour)
+
+var tracer = otel.Tracer("github.com/KonishchevDmitry/feedsd/internal/scraper")
+
+const parametrizedCacheSize = 1000
 
 type SimpleScraper struct {
 	baseScraper
 }
 
-func newSimpleScraper(feed feed.Feed, metrics *baseObservers) *SimpleScraper {
+func newSimpleScraper(feed feed.Feed, pool *workerPool, metrics *baseObservers) *SimpleScraper {
+	baseScraper := makeBaseScraper(feed, pool, metrics)
+	baseScraper.detached = true // We don't want to affect our scrape metrics by closed connections
 	return &SimpleScraper{
-		baseScraper: makeBaseScraper(feed, metrics),
+		baseScraper: baseScraper,
 	}
 }
 
 func (s *SimpleScraper) Scrape(ctx context.Context) ScrapeResult {
-	ctx = context.WithoutCancel(ctx) // We don't want to affect our scrape metrics by closed connections
 	return s.scrape(ctx)
 }
 
 type SimpleParametrizedScraper[P feed.Params] struct {
-	feed    feed.ParametrizedFeed[P]
-	metrics *baseObservers
+	feed         feed.ParametrizedFeed[P]
+	pool         *workerPool
+	metrics      *baseObservers
+	cacheMetrics *cacheObservers
+
+	cache mo.Option[cache.Cache[string, ScrapeResult]]
+
+	lock    sync.Mutex
+	flights map[string]*scrapeFlight
+}
+
+// A scrape shared by concurrent requests with the same parameters. It isn't bound to any of the requests and is
+// cancelled only when all of them are gone.
+type scrapeFlight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+
+	done   chan struct{}
+	result ScrapeResult
 }
 
-func newSimpleParametrizedScraper[P feed.Params](feed feed.ParametrizedFeed[P], metrics *baseObservers) *SimpleParametrizedScraper[P] {
-	return &SimpleParametrizedScraper[P]{
-		feed:    feed,
-		metrics: metrics,
+func newSimpleParametrizedScraper[P feed.Params](
+	feed feed.ParametrizedFeed[P], config Config, pool *workerPool, metrics *baseObservers, cacheMetrics *cacheObservers,
+) *SimpleParametrizedScraper[P] {
+	scraper := &SimpleParametrizedScraper[P]{
+		feed:         feed,
+		pool:         pool,
+		metrics:      metrics,
+		cacheMetrics: cacheMetrics,
+		flights:      make(map[string]*scrapeFlight),
+	}
+	if ttl := config.ParametrizedCacheTTL; ttl > 0 {
+		scraper.cache = mo.Some(cache.NewCache[string, ScrapeResult]().WithTTL(ttl).WithMaxKeys(parametrizedCacheSize))
 	}
+	return scraper
 }
 
 func (s *SimpleParametrizedScraper[P]) Scrape(ctx context.Context, params P) ScrapeResult {
+	key := params.Format()
+
+	resultCache, cacheEnabled := s.cache.Get()
+	if cacheEnabled {
+		if result, ok := resultCache.Get(key); ok {
+			s.cacheMetrics.hits.Inc()
+			return result
+		}
+	}
+
+	// Coalesce concurrent requests with the same parameters into one scrape
+	flight, started := s.joinFlight(ctx, key)
+	defer s.leaveFlight(key, flight)
+
+	if started {
+		go func() {
+			defer close(flight.done)
+
+			flight.result = s.scrape(flight.ctx, params, key)
+			if cacheEnabled && flight.result.HTTPStatus == http.StatusOK {
+				resultCache.Add(key, flight.result)
+			}
+
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			if s.flights[key] == flight {
+				delete(s.flights, key)
+			}
+		}()
+	}
+
+	select {
+	case <-flight.done:
+		if started {
+			s.cacheMetrics.misses.Inc()
+		} else {
+			s.cacheMetrics.coalesced.Inc()
+		}
+		return flight.result
+
+	case <-ctx.Done():
+		return makeErrorResult(http.StatusGatewayTimeout, ctx.Err())
+	}
+}
+
+func (s *SimpleParametrizedScraper[P]) scrape(ctx context.Context, params P, key string) ScrapeResult {
 	// Attention: Binding changes feed name, so be careful and construct metric observers before the binding
 	boundFeed := feed.BindParams(s.feed, params)
-	return newSimpleScraper(boundFeed, s.metrics).Scrape(ctx)
+
+	feedScraper := newSimpleScraper(boundFeed, s.pool, s.metrics)
+	feedScraper.spanAttributes = []attribute.KeyValue{
+		attribute.String("feed.name", s.feed.Name()),
+		attribute.String("feed.params", key),
+	}
+
+	return feedScraper.Scrape(ctx)
+}
+
+func (s *SimpleParametrizedScraper[P]) joinFlight(ctx context.Context, key string) (*scrapeFlight, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if flight, ok := s.flights[key]; ok {
+		flight.waiters++
+		return flight, false
+	}
+
+	flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	flight := &scrapeFlight{
+		ctx:     flightCtx,
+		cancel:  cancel,
+		waiters: 1,
+		done:    make(chan struct{}),
+	}
+	s.flights[key] = flight
+
+	return flight, true
+}
+
+func (s *SimpleParametrizedScraper[P]) leaveFlight(key string, flight *scrapeFlight) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	flight.waiters--
+	if flight.waiters != 0 {
+		return
+	}
+
+	// Nobody needs the result anymore, so abandon the scrape and let the following requests start a new one
+	if s.flights[key] == flight {
+		delete(s.flights, key)
+	}
+	flight.cancel()
 }
 
 type BackgroundScraper struct {
 	baseScraper
 	backgroundMetrics *backgroundObservers
+	schedule          schedule.Schedule
 
 	force     chan struct{}
+	cancel    context.CancelFunc
 	stopped   chan struct{}
+	stopOnce  sync.Once
 	waitGroup sync.WaitGroup
 
-	lock    util.GuardedLock
-	result  mo.Option[ScrapeResult]
-	waiters []chan<- ScrapeResult
+	snapshots mo.Option[*storage.Storage]
+	webSub    mo.Option[WebSubConfig]
+
+	retryPolicy RetryPolicy
+
+	lock          util.GuardedLock
+	staleMaxAge   time.Duration
+	failureNotice mo.Option[time.Duration]
+	lastScrape    mo.Option[time.Time]
+	result        mo.Option[ScrapeResult]
+	lastSuccess   mo.Option[ScrapeResult]
+	lastError     mo.Option[ScrapeResult]
+	failingSince  mo.Option[time.Time]
+	lastNewItem   mo.Option[time.Time]
+	notice        mo.Option[ScrapeResult]
+	waiters       []chan<- ScrapeResult
 }
 
-func newBackgroundScraper(feed feed.Feed, baseMetrics *baseObservers, backgroundMetrics *backgroundObservers) *BackgroundScraper {
+func newBackgroundScraper(
+	scrapedFeed feed.Feed, config Config, pool *workerPool, baseMetrics *baseObservers, backgroundMetrics *backgroundObservers,
+) *BackgroundScraper {
+	scrapeSchedule := defaultSchedule
+	if scheduledFeed, ok := feed.As[feed.ScheduledFeed](scrapedFeed); ok {
+		scrapeSchedule = scheduledFeed.Schedule()
+	}
+
+	baseScraper := makeBaseScraper(scrapedFeed, pool, baseMetrics)
+	if historyFeed, ok := feed.As[feed.HistoryFeed](scrapedFeed); ok {
+		baseScraper.history = mo.Some(newItemHistory(scrapedFeed.Name(), historyFeed.History()))
+	}
+	if webSub, ok := config.WebSub.Get(); ok {
+		baseScraper.links = mo.Some(makeFeedLinks(webSub, scrapedFeed.Name()))
+	}
+
 	return &BackgroundScraper{
-		baseScraper:       makeBaseScraper(feed, baseMetrics),
+		baseScraper:       baseScraper,
 		backgroundMetrics: backgroundMetrics,
+		schedule:          scrapeSchedule,
 
 		force:   make(chan struct{}, 1),
 		stopped: make(chan struct{}),
+
+		webSub: config.WebSub,
+
+		retryPolicy:   config.Retry,
+		staleMaxAge:   config.StaleMaxAge,
+		failureNotice: config.FailureNotice,
 	}
 }
 
-func (s *BackgroundScraper) start(ctx context.Context, develMode bool) {
+func (s *BackgroundScraper) start(ctx context.Context, state mo.Option[*stateStorage], develMode bool) {
+	if state, ok := state.Get(); ok {
+		// The scraper might have inherited a newer state from the replaced one
+		if !s.HasResult() {
+			s.loadSnapshot(ctx, state.snapshots)
+		}
+		s.snapshots = mo.Some(state.snapshots)
+		if history, ok := s.history.Get(); ok {
+			history.setStorage(state.history)
+		}
+	}
+
+	// Stopping cancels the in-flight scrape, so the scraper may be stopped quickly
+	daemonCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
 	s.backgroundMetrics.startTime().SetToCurrentTime()
 	s.waitGroup.Go(func() {
-		s.daemon(ctx, develMode)
+		s.daemon(daemonCtx, develMode)
 	})
 }
 
 func (s *BackgroundScraper) stop(ctx context.Context) {
 	logging.L(ctx).Infof("Stopping %s scrapper...", s.feed.Name())
-	close(s.stopped)
+	s.stopOnce.Do(func() {
+		close(s.stopped)
+		if s.cancel != nil {
+			s.cancel()
+		}
+	})
 	s.waitGroup.Wait()
 	logging.L(ctx).Infof("%s scrapper has stopped.", s.feed.Name())
 }
 
+// Takes over the results of the scraper which is being replaced by this one. Must be called before the start.
+func (s *BackgroundScraper) inherit(other *BackgroundScraper) {
+	lock := other.lock.Lock()
+	defer lock.Unlock()
+
+	s.lastScrape = other.lastScrape
+	s.result = other.result
+	s.lastSuccess = other.lastSuccess
+	s.lastError = other.lastError
+	s.failingSince = other.failingSince
+	s.lastNewItem = other.lastNewItem
+	s.notice = other.notice
+}
+
 func (s *BackgroundScraper) Get(ctx context.Context) ScrapeResult {
 	lock := s.lock.Lock()
 	defer lock.UnlockIfLocked()
 
-	if result, ok := s.result.Get(); ok {
-		return result
+	if _, ok := s.result.Get(); ok {
+		return s.getResult()
 	}
 
 	waiter := make(chan ScrapeResult, 1)
 	s.waiters = append(s.waiters, waiter)
 	lock.Unlock()
 
-	select {
-	case s.force <- struct{}{}:
-	default:
-	}
+	s.Refresh()
 
 	select {
 	case result := <-waiter:
 		return result
 
 	case <-s.stopped:
-		return makeErrorResult(http.StatusServiceUnavailable)
+		return makeErrorResult(http.StatusServiceUnavailable, errors.New("the scraper is stopped"))
 
 	case <-ctx.Done():
 		lock.Lock()
 		if index := slices.Index(s.waiters, waiter); index != -1 {
 			s.waiters = slices.Delete(s.waiters, index, index+1)
 		}
-		return makeErrorResult(http.StatusGatewayTimeout)
+		return makeErrorResult(http.StatusGatewayTimeout, ctx.Err())
+	}
+}
+
+// LastError returns the result of the last failed scrape if it's not followed by a successful one.
+func (s *BackgroundScraper) LastError() (ScrapeResult, bool) {
+	lock := s.lock.Lock()
+	defer lock.Unlock()
+	return s.lastError.Get()
+}
+
+// HasResult returns true if the feed has been scraped at least once (or restored from a snapshot)
+func (s *BackgroundScraper) HasResult() bool {
+	lock := s.lock.Lock()
+	defer lock.Unlock()
+	return s.result.IsPresent()
+}
+
+// Must be called under the lock
+func (s *BackgroundScraper) getResult() ScrapeResult {
+	result := s.result.MustGet()
+	if result.HTTPStatus == http.StatusOK {
+		return result
+	}
+
+	if notice, ok := s.getFailureNotice(); ok {
+		return notice
+	}
+
+	// Serve the last good feed instead of the error until it becomes too old
+	if lastSuccess, ok := s.lastSuccess.Get(); ok && time.Since(lastSuccess.Time) < s.staleMaxAge {
+		lastSuccess.Stale = true
+		return lastSuccess
+	}
+
+	return result
+}
+
+// Must be called under the lock
+func (s *BackgroundScraper) getFailureNotice() (ScrapeResult, bool) {
+	notice, ok := s.notice.Get()
+	if !ok {
+		return ScrapeResult{}, false
+	}
+
+	failingSince, ok := s.failingSince.Get()
+	if !ok || time.Since(failingSince) < s.failureNotice.MustGet() {
+		return ScrapeResult{}, false
+	}
+
+	return notice, true
+}
+
+// Rendering and compression of the failure notice are expensive, so it's prepared in advance outside of the lock and
+// is served only when the feed fails long enough
+func (s *BackgroundScraper) prepareFailureNotice(
+	lastSuccess mo.Option[ScrapeResult], lastError ScrapeResult, failingSince time.Time,
+) mo.Option[ScrapeResult] {
+	if s.failureNotice.IsAbsent() {
+		return mo.None[ScrapeResult]()
+	}
+
+	success, ok := lastSuccess.Get()
+	if !ok {
+		return mo.None[ScrapeResult]()
+	}
+
+	notice, err := makeFailureNotice(s.feed.Name(), success, lastError, failingSince, s.links)
+	if err != nil {
+		return mo.None[ScrapeResult]()
+	}
+
+	notice.precompress()
+	return mo.Some(notice)
+}
+
+// Refresh forces an immediate rescrape of the feed
+func (s *BackgroundScraper) Refresh() {
+	select {
+	case s.force <- struct{}{}:
+	default:
 	}
 }
 
 func (s *BackgroundScraper) daemon(ctx context.Context, develMode bool) {
-	forceChan := s.force
-	infiniteChan := make(chan struct{})
+	lock := s.lock.Lock()
+	lastScrape := s.lastScrape
+	lock.Unlock()
 
-	updateTimer := time.NewTimer(rand.N(scrapePeriod))
+	var firstScrapeDelay time.Duration
+	if lastScrape, ok := lastScrape.Get(); ok {
+		firstScrapeDelay = s.nextScrapeDelay(lastScrape)
+	} else {
+		firstScrapeDelay = getFirstScrapeDelay(s.schedule, time.Now())
+	}
+
+	updateTimer := time.NewTimer(firstScrapeDelay)
 	defer updateTimer.Stop()
 
 	updateChan := updateTimer.C
@@ -137,11 +423,13 @@ func (s *BackgroundScraper) daemon(ctx context.Context, develMode bool) {
 		updateChan = make(chan time.Time)
 	}
 
+	var retryAttempt int
+
 	for {
 		select {
 		case <-updateChan:
 
-		case <-forceChan:
+		case <-s.force:
 			updateTimer.Stop()
 			select {
 			case <-updateTimer.C:
@@ -153,38 +441,176 @@ func (s *BackgroundScraper) daemon(ctx context.Context, develMode bool) {
 		}
 
 		result := s.scrape(ctx)
-		if result.HTTPStatus == http.StatusOK {
-			s.backgroundMetrics.feedTime().SetToCurrentTime()
+		if ctx.Err() != nil {
+			// The scraper is being stopped and the result is an error caused by the cancellation
+			return
+		}
+		result.precompress()
+
+		s.trackChanges(ctx, result)
+		changed := s.update(result)
+		s.saveSnapshot(ctx)
+		if changed {
+			s.publish(ctx, result)
+		}
+
+		delay := s.nextScrapeDelay(result.Time)
+		if util.IsTemporaryError(result.Error) {
+			if retryDelay, ok := s.retryPolicy.delay(retryAttempt); ok && retryDelay < delay {
+				retryAttempt++
+				s.backgroundMetrics.retries().Inc()
+				logging.L(ctx).Infof("Retrying %s feed scraping in %s (attempt #%d)...",
+					s.feed.Name(), retryDelay.Round(time.Second), retryAttempt)
+				delay = retryDelay
+			} else {
+				retryAttempt = 0
+			}
+		} else {
+			retryAttempt = 0
 		}
 
+		// Drop refresh requests which have been received during the scrape
+		select {
+		case <-s.force:
+		default:
+		}
+
+		updateTimer.Reset(delay)
+	}
+}
+
+// Returns true if the scrape has produced a new version of the feed
+func (s *BackgroundScraper) update(result ScrapeResult) bool {
+	if result.HTTPStatus == http.StatusOK {
+		s.backgroundMetrics.feedTime().SetToCurrentTime()
+	} else {
+		s.backgroundMetrics.errorTime().SetToCurrentTime()
+	}
+
+	// Only the daemon modifies the state, so it doesn't change until we take the lock again
+	var notice mo.Option[ScrapeResult]
+	if result.HTTPStatus != http.StatusOK {
 		lock := s.lock.Lock()
-		s.result = mo.Some(result)
-		waiters := s.waiters
-		s.waiters = nil
+		lastSuccess, failingSince := s.lastSuccess, s.failingSince.OrElse(result.Time)
 		lock.Unlock()
+		notice = s.prepareFailureNotice(lastSuccess, result, failingSince)
+	}
 
-		for _, waiter := range waiters {
-			waiter <- result
+	lock := s.lock.Lock()
+	s.lastScrape = mo.Some(result.Time)
+	changed := result.HTTPStatus == http.StatusOK
+	if lastSuccess, ok := s.lastSuccess.Get(); ok && changed && result.ETag == lastSuccess.ETag {
+		result.LastModified = lastSuccess.LastModified
+		result.formats = lastSuccess.formats // Might depend on the modification time
+		changed = false
+	}
+	s.result = mo.Some(result)
+	s.notice = notice
+	if result.HTTPStatus == http.StatusOK {
+		s.lastSuccess = mo.Some(result)
+		s.lastError = mo.None[ScrapeResult]()
+		s.failingSince = mo.None[time.Time]()
+	} else {
+		s.lastError = mo.Some(result)
+		if s.failingSince.IsAbsent() {
+			s.failingSince = mo.Some(result.Time)
 		}
+	}
+	result = s.getResult()
+	waiters := s.waiters
+	s.waiters = nil
+	lock.Unlock()
+
+	for _, waiter := range waiters {
+		waiter <- result
+	}
+
+	return changed
+}
+
+func (s *BackgroundScraper) nextScrapeDelay(lastScrape time.Time) time.Duration {
+	return max(0, time.Until(s.schedule.Next(lastScrape)))
+}
 
-		updateTimer.Reset(scrapePeriod)
-		forceChan = infiniteChan
+// Spreads the first scrapes of all feeds across their first scrape period. The jitter is applied to the schedule's input
+// rather than to its output, so the scrape time is always the one returned by the schedule (within its time windows for
+// example).
+func getFirstScrapeDelay(feedSchedule schedule.Schedule, now time.Time) time.Duration {
+	next := feedSchedule.Next(now)
+
+	first := feedSchedule.Next(now.Add(-randomDelay(next.Sub(now))))
+	if first.Before(now) {
+		first = next
+	}
+
+	return first.Sub(now)
+}
+
+func randomDelay(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return 0
 	}
+	return rand.N(delay)
 }
 
 type baseScraper struct {
 	feed        feed.Feed
+	pool        *workerPool
 	baseMetrics *baseObservers
+	history     mo.Option[*itemHistory]
+	links       mo.Option[feedLinks]
+
+	spanAttributes []attribute.KeyValue
+
+	// Detached scrapes can be cancelled only while they wait for a worker
+	detached bool
 }
 
-func makeBaseScraper(feed feed.Feed, metrics *baseObservers) baseScraper {
+func makeBaseScraper(feed feed.Feed, pool *workerPool, metrics *baseObservers) baseScraper {
 	return baseScraper{
 		feed:        feed,
+		pool:        pool,
 		baseMetrics: metrics,
+
+		spanAttributes: []attribute.KeyValue{attribute.String("feed.name", feed.Name())},
 	}
 }
 
 func (s *baseScraper) scrape(ctx context.Context) ScrapeResult {
+	ctx, span := tracer.Start(ctx, "scrape", trace.WithAttributes(s.spanAttributes...))
+
+	release, err := s.pool.acquire(ctx, s.baseMetrics.queueWait)
+	if err != nil {
+		logging.L(ctx).Debugf("Cancelling %s scraping: %s.", s.feed.Name(), err)
+		util.EndSpan(span, err)
+		return makeErrorResult(http.StatusServiceUnavailable, err)
+	}
+	defer release()
+	span.AddEvent("acquired a worker")
+
+	if s.detached {
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	startTime := time.Now()
+	result, status := s.generate(ctx)
+	var category string
+	if result.Error != nil {
+		category = util.GetErrorCategory(result.Error)
+	}
+	s.baseMetrics.feedStatus.WithLabelValues(status, category).Inc()
+	s.baseMetrics.status.observe(startTime, time.Since(startTime), status, result)
+
+	span.SetAttributes(attribute.String("feed.status", status))
+	if feed, ok := result.Feed(); ok {
+		span.SetAttributes(attribute.Int("feed.items", len(feed.Items)))
+	}
+	util.EndSpan(span, result.Error)
+
+	return result
+}
+
+func (s *baseScraper) generate(ctx context.Context) (ScrapeResult, string) {
 	ctx = fetch.WithContext(ctx, s.baseMetrics.fetchDuration)
 	logging.L(ctx).Infof("Scraping %s feed...", s.feed.Name())
 
@@ -203,52 +629,28 @@ func (s *baseScraper) scrape(ctx context.Context) ScrapeResult {
 
 	if panicErr != nil {
 		logging.L(ctx).Errorf("Failed to scrape %s feed: %s", s.feed.Name(), panicErr)
-		s.baseMetrics.feedStatus.WithLabelValues(feedStatusPanic).Inc()
-		return makeErrorResult(http.StatusInternalServerError)
+		return makeErrorResult(http.StatusInternalServerError, panicErr), feedStatusPanic
 	} else if util.IsTemporaryError(err) {
-		logging.L(ctx).Warnf("Failed to scrape %s feed: %s.", s.feed.Name(), err)
-		s.baseMetrics.feedStatus.WithLabelValues(feedStatusUnavailable).Inc()
-		return makeErrorResult(http.StatusGatewayTimeout)
+		logging.L(ctx).Warnf("Failed to scrape %s feed (%s): %s.", s.feed.Name(), util.GetErrorCategory(err), err)
+		return makeErrorResult(http.StatusGatewayTimeout, err), feedStatusUnavailable
 	} else if err != nil {
-		logging.L(ctx).Errorf("Failed to scrape %s feed: %s.", s.feed.Name(), err)
-		s.baseMetrics.feedStatus.WithLabelValues(feedStatusError).Inc()
-		return makeErrorResult(http.StatusBadGateway)
+		logging.L(ctx).Errorf("Failed to scrape %s feed (%s): %s.", s.feed.Name(), util.GetErrorCategory(err), err)
+		return makeErrorResult(http.StatusBadGateway, err), feedStatusError
 	}
 
 	logging.L(ctx).Infof("%s feed scraped.", s.feed.Name())
 	feed.Normalize()
 
-	data, err := rss.Generate(feed)
-	if err != nil {
-		logging.L(ctx).Errorf("Failed to render %s RSS feed: %s.", s.feed.Name(), err)
-		s.baseMetrics.feedStatus.WithLabelValues(feedStatusError).Inc()
-		return makeErrorResult(http.StatusInternalServerError)
+	if history, ok := s.history.Get(); ok {
+		history.merge(ctx, feed)
 	}
 
-	s.baseMetrics.feedStatus.WithLabelValues(feedStatusSuccess).Inc()
-	return makeScrapeResult(http.StatusOK, rss.ContentType, data)
-}
-
-type ScrapeResult struct {
-	HTTPStatus  int
-	ContentType string
-	Data        []byte
-}
-
-func makeScrapeResult(status int, contentType string, data []byte) ScrapeResult {
-	return ScrapeResult{
-		HTTPStatus:  status,
-		ContentType: contentType,
-		Data:        data,
+	result, err := makeFeedResult(feed, s.links)
+	if err != nil {
+		logging.L(ctx).Errorf("Failed to render %s feed: %s.", s.feed.Name(), err)
+		err = util.WithErrorCategory(err, util.ErrorCategoryRender)
+		return makeErrorResult(http.StatusInternalServerError, err), feedStatusError
 	}
-}
-
-func makeErrorResult(status int) ScrapeResult {
-	return makeScrapeResult(status, "text/plain", []byte("Failed to generate the RSS feed"))
-}
 
-func (r *ScrapeResult) Write(writer http.ResponseWriter) {
-	writer.Header().Set("Content-Type", r.ContentType)
-	writer.WriteHeader(r.HTTPStatus)
-	_, _ = writer.Write(r.Data)
+	return result, feedStatusSuccess
 }
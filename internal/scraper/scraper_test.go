@@ -0,0 +1,464 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/internal/storage"
+	"github.com/KonishchevDmitry/feedsd/internal/util"
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/schedule"
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+func TestStaleResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test"}
+
+	scraper, err := NewRegistry(DefaultConfig()).Add(feed)
+	require.NoError(t, err)
+
+	scraper.update(scraper.scrape(ctx))
+	result := scraper.Get(ctx)
+	require.Equal(t, http.StatusOK, result.HTTPStatus)
+	require.False(t, result.Stale)
+	_, ok := scraper.LastError()
+	require.False(t, ok)
+
+	feed.err = errors.New("some error")
+	scraper.update(scraper.scrape(ctx))
+
+	staleResult := scraper.Get(ctx)
+	require.Equal(t, http.StatusOK, staleResult.HTTPStatus)
+	require.True(t, staleResult.Stale)
+	require.Equal(t, result.Data, staleResult.Data)
+
+	lastError, ok := scraper.LastError()
+	require.True(t, ok)
+	require.Equal(t, http.StatusBadGateway, lastError.HTTPStatus)
+	require.ErrorIs(t, lastError.Error, feed.err)
+
+	scraper.staleMaxAge = 0
+	require.Equal(t, http.StatusBadGateway, scraper.Get(ctx).HTTPStatus)
+}
+
+func TestFailureNotice(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test"}
+
+	config := DefaultConfig()
+	config.FailureNotice = mo.Some(time.Hour)
+
+	scraper, err := NewRegistry(config).Add(feed)
+	require.NoError(t, err)
+
+	scraper.update(scraper.scrape(ctx))
+	lastSuccess := scraper.Get(ctx)
+
+	getItems := func() []*rss.Item {
+		result := scraper.Get(ctx)
+		require.Equal(t, http.StatusOK, result.HTTPStatus)
+		parsed, err := rss.Parse(result.Data)
+		require.NoError(t, err)
+		return parsed.Items
+	}
+
+	feed.err = errors.New("some error")
+	scraper.update(scraper.scrape(ctx))
+	require.Equal(t, lastSuccess.Data, scraper.Get(ctx).Data)
+
+	// Pretend that the outage has started a while ago
+	lock := scraper.lock.Lock()
+	failingSince := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	scraper.failingSince = mo.Some(failingSince)
+	lock.Unlock()
+
+	// The notice is prepared on the scrape
+	scraper.update(scraper.scrape(ctx))
+	items := getItems()
+	require.Len(t, items, 2)
+	require.Equal(t, "test feed is failing", items[0].Title)
+	guid := items[0].GUID.ID
+
+	// The notice must stay the same during the whole outage
+	scraper.update(scraper.scrape(ctx))
+	items = getItems()
+	require.Len(t, items, 2)
+	require.Equal(t, guid, items[0].GUID.ID)
+
+	feed.err = nil
+	scraper.update(scraper.scrape(ctx))
+	items = getItems()
+	require.Len(t, items, 1)
+	require.Equal(t, "Test item", items[0].Title)
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test"}
+	registry := NewRegistry(DefaultConfig())
+
+	scraper, err := registry.Add(feed)
+	require.NoError(t, err)
+	scraper.update(scraper.scrape(ctx))
+	require.Equal(t, 1, promtestutil.CollectAndCount(registry.metrics.feedStatus))
+
+	require.NoError(t, registry.Remove(ctx, feed.name))
+	require.ErrorIs(t, registry.Remove(ctx, feed.name), ErrUnknownFeed)
+	require.ErrorIs(t, registry.Refresh(feed.name), ErrUnknownFeed)
+	require.Zero(t, promtestutil.CollectAndCount(registry.metrics.feedStatus))
+	require.Empty(t, registry.Statuses())
+
+	_, err = registry.Add(feed)
+	require.NoError(t, err)
+	require.Len(t, registry.Statuses(), 1)
+}
+
+func TestTrackChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test"}
+	registry := NewRegistry(DefaultConfig())
+
+	scraper, err := registry.Add(feed)
+	require.NoError(t, err)
+
+	scrape := func(items ...string) {
+		feed.items = items
+		result := scraper.scrape(ctx)
+		scraper.trackChanges(ctx, result)
+		scraper.update(result)
+	}
+
+	scrape("1", "2")
+	require.Equal(t, 2.0, promtestutil.ToFloat64(registry.metrics.items.WithLabelValues(feed.name)))
+	require.Zero(t, promtestutil.CollectAndCount(registry.metrics.newItems))
+	require.False(t, scraper.lastNewItem.IsPresent())
+
+	scrape("2", "3", "4")
+	require.Equal(t, 3.0, promtestutil.ToFloat64(registry.metrics.items.WithLabelValues(feed.name)))
+	require.Equal(t, 2.0, promtestutil.ToFloat64(registry.metrics.newItems.WithLabelValues(feed.name)))
+	lastNewItem := scraper.lastNewItem.MustGet()
+
+	scrape("3", "4")
+	require.Equal(t, 2.0, promtestutil.ToFloat64(registry.metrics.newItems.WithLabelValues(feed.name)))
+	require.Equal(t, lastNewItem, scraper.lastNewItem.MustGet())
+}
+
+func TestErrorCategory(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test"}
+	registry := NewRegistry(DefaultConfig())
+
+	scraper, err := registry.Add(feed)
+	require.NoError(t, err)
+
+	getCount := func(status string, category string) float64 {
+		return promtestutil.ToFloat64(registry.metrics.feedStatus.WithLabelValues(feed.name, status, category))
+	}
+
+	scraper.scrape(ctx)
+	require.Equal(t, 1.0, getCount(feedStatusSuccess, ""))
+
+	feed.err = errors.New("some error")
+	scraper.scrape(ctx)
+	require.Equal(t, 1.0, getCount(feedStatusError, util.ErrorCategoryOther))
+
+	feed.err = fmt.Errorf("wrapped: %w", categorizedTestError{})
+	scraper.scrape(ctx)
+	require.Equal(t, 1.0, getCount(feedStatusError, util.ErrorCategoryHTTP4xx))
+}
+
+type categorizedTestError struct{}
+
+func (e categorizedTestError) Error() string {
+	return "forbidden"
+}
+
+func (e categorizedTestError) ErrorCategory() string {
+	return util.ErrorCategoryHTTP4xx
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test(param=value)"}
+
+	snapshots, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	scraper, err := NewRegistry(DefaultConfig()).Add(feed)
+	require.NoError(t, err)
+	scraper.snapshots = mo.Some(snapshots)
+
+	scraper.update(scraper.scrape(ctx))
+	feed.err = errors.New("some error")
+	scraper.update(scraper.scrape(ctx))
+	scraper.saveSnapshot(ctx)
+
+	restoredScraper, err := NewRegistry(DefaultConfig()).Add(feed)
+	require.NoError(t, err)
+	restoredScraper.loadSnapshot(ctx, snapshots)
+
+	require.True(t, scraper.lastScrape.MustGet().Equal(restoredScraper.lastScrape.MustGet()))
+	require.Equal(t, scraper.Get(ctx).Data, restoredScraper.Get(ctx).Data)
+
+	// Alternative formats must be available for the restored feed as well
+	restored := restoredScraper.Get(ctx)
+	atom := restored.Format(FormatAtom)
+	require.Equal(t, http.StatusOK, atom.HTTPStatus)
+	require.Equal(t, rss.AtomContentType, atom.ContentType)
+
+	lastError, ok := restoredScraper.LastError()
+	require.True(t, ok)
+	require.EqualError(t, lastError.Error, feed.err.Error())
+
+	// The status must reflect the restored state of the feed
+	status := restoredScraper.baseMetrics.status.get()
+	require.True(t, status.LastSuccess.Equal(scraper.lastSuccess.MustGet().Time))
+	require.True(t, status.LastFailure.Equal(lastError.Time))
+	require.Equal(t, feed.err.Error(), status.LastError)
+	require.Equal(t, 1, status.ErrorsInARow)
+	require.Equal(t, 1, status.Items)
+}
+
+func TestConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testFeed{name: "test"}
+
+	scraper, err := NewRegistry(DefaultConfig()).Add(feed)
+	require.NoError(t, err)
+
+	scraper.update(scraper.scrape(ctx))
+	result := scraper.Get(ctx)
+
+	// Make sure that modification time is preserved when content isn't changed
+	lastModified := result.LastModified.Add(-time.Hour)
+	scraper.lastSuccess = mo.Some(ScrapeResult{ETag: result.ETag, LastModified: lastModified})
+	scraper.update(scraper.scrape(ctx))
+	result = scraper.Get(ctx)
+	require.Equal(t, lastModified, result.LastModified)
+
+	for _, testCase := range []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+		body    bool
+	}{{
+		name:   "get",
+		method: http.MethodGet,
+		status: http.StatusOK,
+		body:   true,
+	}, {
+		name:   "head",
+		method: http.MethodHead,
+		status: http.StatusOK,
+	}, {
+		name:    "if-none-match",
+		method:  http.MethodGet,
+		headers: map[string]string{"If-None-Match": result.ETag},
+		status:  http.StatusNotModified,
+	}, {
+		name:    "if-none-match-changed",
+		method:  http.MethodGet,
+		headers: map[string]string{"If-None-Match": `"other"`},
+		status:  http.StatusOK,
+		body:    true,
+	}, {
+		name:    "if-modified-since",
+		method:  http.MethodGet,
+		headers: map[string]string{"If-Modified-Since": time.Now().UTC().Format(http.TimeFormat)},
+		status:  http.StatusNotModified,
+	}} {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequest(testCase.method, "/test.rss", nil)
+			for name, value := range testCase.headers {
+				request.Header.Set(name, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			result.Write(recorder, request)
+
+			require.Equal(t, testCase.status, recorder.Code)
+			require.Equal(t, result.ETag, recorder.Header().Get("ETag"))
+			if testCase.status == http.StatusOK {
+				require.Equal(t, lastModified.UTC().Format(http.TimeFormat), recorder.Header().Get("Last-Modified"))
+			}
+			if testCase.body {
+				require.Equal(t, result.Data, recorder.Body.Bytes())
+			} else {
+				require.Empty(t, recorder.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{
+		MaxAttempts:  5,
+		InitialDelay: time.Minute,
+		MaxDelay:     5 * time.Minute,
+	}
+
+	for attempt, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		delay, ok := policy.delay(attempt)
+		require.True(t, ok)
+		require.LessOrEqual(t, delay, expected)
+		require.GreaterOrEqual(t, delay, expected/2)
+	}
+
+	_, ok := policy.delay(policy.MaxAttempts)
+	require.False(t, ok)
+}
+
+func TestFirstScrapeDelay(t *testing.T) {
+	t.Parallel()
+
+	location, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	now := time.Date(2025, 9, 12, 11, 30, 0, 0, location)
+	window := schedule.Windows(time.Hour, location, schedule.MustParseWindow("08:00-12:00"))
+
+	for range 100 {
+		delay := getFirstScrapeDelay(defaultSchedule, now)
+		require.Greater(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, time.Hour)
+
+		// The first scrape must never land outside of the time window
+		first := now.Add(getFirstScrapeDelay(window, now))
+		require.True(t, first.Hour() >= 8 && first.Hour() < 12, first)
+	}
+}
+
+type testFeed struct {
+	name  string
+	err   error
+	items []string
+}
+
+func (f *testFeed) Name() string {
+	return f.name
+}
+
+func (f *testFeed) Get(ctx context.Context) (*rss.Feed, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	feed := rss.NewFeed("Test feed", url.MustParse("https://example.com/"))
+	if f.items == nil {
+		feed.AddItem(time.Now(), "Test item", url.MustParse("https://example.com/item"), "Test description")
+	}
+	for _, item := range f.items {
+		feed.AddItem(time.Now(), item, url.MustParse("https://example.com/"+item), "")
+	}
+	return feed, nil
+}
+
+func TestParametrizedCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	feed := &testParametrizedFeed{
+		release: make(chan struct{}),
+	}
+
+	scraper, err := AddParametrized(NewRegistry(DefaultConfig()), feed)
+	require.NoError(t, err)
+
+	const concurrency = 10
+
+	params := testParams{value: "value"}
+	results := make(chan ScrapeResult, concurrency)
+	for range concurrency {
+		go func() {
+			results <- scraper.Scrape(ctx, params)
+		}()
+	}
+
+	// Hold the scrape until all callers join it
+	require.Eventually(t, func() bool {
+		return getFlightWaiters(scraper, params) == concurrency
+	}, time.Second, time.Millisecond)
+	close(feed.release)
+
+	for range concurrency {
+		require.Equal(t, http.StatusOK, (<-results).HTTPStatus)
+	}
+	require.Equal(t, int64(1), feed.calls.Load())
+	require.Equal(t, float64(1), promtestutil.ToFloat64(scraper.cacheMetrics.misses))
+	require.Equal(t, float64(concurrency-1), promtestutil.ToFloat64(scraper.cacheMetrics.coalesced))
+
+	require.Equal(t, http.StatusOK, scraper.Scrape(ctx, testParams{value: "value"}).HTTPStatus)
+	require.Equal(t, int64(1), feed.calls.Load())
+
+	require.Equal(t, http.StatusOK, scraper.Scrape(ctx, testParams{value: "other"}).HTTPStatus)
+	require.Equal(t, int64(2), feed.calls.Load())
+}
+
+type testParams struct {
+	value string
+}
+
+func (p testParams) Format() string {
+	return "value=" + p.value
+}
+
+func getFlightWaiters[P feed.Params](scraper *SimpleParametrizedScraper[P], params P) int {
+	scraper.lock.Lock()
+	defer scraper.lock.Unlock()
+
+	if flight, ok := scraper.flights[params.Format()]; ok {
+		return flight.waiters
+	}
+	return 0
+}
+
+type testParametrizedFeed struct {
+	calls   atomic.Int64
+	release chan struct{}
+}
+
+func (f *testParametrizedFeed) Name() string {
+	return "test"
+}
+
+func (f *testParametrizedFeed) Path() (string, bool) {
+	return "", false
+}
+
+func (f *testParametrizedFeed) Get(ctx context.Context, params testParams) (*rss.Feed, error) {
+	f.calls.Add(1)
+	<-f.release
+	return rss.NewFeed("Test feed: "+params.value, url.MustParse("https://example.com/")), nil
+}
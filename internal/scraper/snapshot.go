@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/internal/storage"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+type snapshot struct {
+	ScrapeTime   time.Time       `json:"scrape_time"`
+	LastSuccess  *snapshotResult `json:"last_success,omitempty"`
+	LastError    *snapshotResult `json:"last_error,omitempty"`
+	FailingSince time.Time       `json:"failing_since,omitzero"`
+	LastNewItem  time.Time       `json:"last_new_item,omitzero"`
+}
+
+type snapshotResult struct {
+	Time         time.Time `json:"time"`
+	HTTPStatus   int       `json:"http_status"`
+	ContentType  string    `json:"content_type"`
+	Data         []byte    `json:"data"`
+	LastModified time.Time `json:"last_modified"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func makeSnapshotResult(result ScrapeResult) *snapshotResult {
+	snapshot := &snapshotResult{
+		Time:         result.Time,
+		HTTPStatus:   result.HTTPStatus,
+		ContentType:  result.ContentType,
+		Data:         result.Data,
+		LastModified: result.LastModified,
+	}
+	if result.Error != nil {
+		snapshot.Error = result.Error.Error()
+	}
+	return snapshot
+}
+
+func (r *snapshotResult) result() ScrapeResult {
+	result := makeScrapeResult(r.HTTPStatus, r.ContentType, r.Data)
+	result.Time = r.Time
+	result.LastModified = r.LastModified
+	if r.Error != "" {
+		result.Error = errors.New(r.Error)
+	}
+	return result
+}
+
+func (s *BackgroundScraper) loadSnapshot(ctx context.Context, snapshots *storage.Storage) {
+	name := s.feed.Name()
+
+	var snapshot snapshot
+	if ok, err := snapshots.Load(name, &snapshot); err != nil {
+		logging.L(ctx).Errorf("Failed to load %s feed snapshot: %s.", name, err)
+		return
+	} else if !ok {
+		return
+	}
+
+	lock := s.lock.Lock()
+	defer lock.Unlock()
+
+	if lastSuccess := snapshot.LastSuccess; lastSuccess != nil && lastSuccess.HTTPStatus == http.StatusOK {
+		result := lastSuccess.result()
+		if feed, err := rss.Parse(result.Data); err != nil {
+			logging.L(ctx).Errorf("Failed to parse %s feed snapshot: %s.", name, err)
+		} else if err := result.setFeed(feed, s.links); err != nil {
+			logging.L(ctx).Errorf("Failed to restore %s feed from the snapshot: %s.", name, err)
+		}
+		result.precompress()
+		s.lastSuccess = mo.Some(result)
+		s.result = mo.Some(result)
+		s.backgroundMetrics.feedTime().Set(float64(result.Time.Unix()))
+		if feed, ok := result.Feed(); ok {
+			s.backgroundMetrics.items().Set(float64(len(feed.Items)))
+		}
+	}
+	if lastNewItem := snapshot.LastNewItem; !lastNewItem.IsZero() {
+		s.lastNewItem = mo.Some(lastNewItem)
+		s.backgroundMetrics.lastNewItem().Set(float64(lastNewItem.Unix()))
+	}
+
+	if lastError := snapshot.LastError; lastError != nil && lastError.HTTPStatus != http.StatusOK {
+		result := lastError.result()
+		s.lastError = mo.Some(result)
+		s.result = mo.Some(result)
+		s.backgroundMetrics.errorTime().Set(float64(result.Time.Unix()))
+
+		failingSince := snapshot.FailingSince
+		if failingSince.IsZero() {
+			failingSince = result.Time
+		}
+		s.failingSince = mo.Some(failingSince)
+		s.notice = s.prepareFailureNotice(s.lastSuccess, result, failingSince)
+	}
+
+	if s.result.IsPresent() {
+		s.lastScrape = mo.Some(snapshot.ScrapeTime)
+		s.baseMetrics.status.restore(snapshot.ScrapeTime, s.lastSuccess, s.lastError, s.failingSince)
+		logging.L(ctx).Debugf("%s feed has been restored from the snapshot.", name)
+	}
+}
+
+func (s *BackgroundScraper) saveSnapshot(ctx context.Context) {
+	snapshots, ok := s.snapshots.Get()
+	if !ok {
+		return
+	}
+
+	var snapshot snapshot
+
+	lock := s.lock.Lock()
+	if lastScrape, ok := s.lastScrape.Get(); ok {
+		snapshot.ScrapeTime = lastScrape
+	}
+	if lastSuccess, ok := s.lastSuccess.Get(); ok {
+		snapshot.LastSuccess = makeSnapshotResult(lastSuccess)
+	}
+	if lastError, ok := s.lastError.Get(); ok {
+		snapshot.LastError = makeSnapshotResult(lastError)
+	}
+	if failingSince, ok := s.failingSince.Get(); ok {
+		snapshot.FailingSince = failingSince
+	}
+	if lastNewItem, ok := s.lastNewItem.Get(); ok {
+		snapshot.LastNewItem = lastNewItem
+	}
+	lock.Unlock()
+
+	if err := snapshots.Save(s.feed.Name(), &snapshot); err != nil {
+		logging.L(ctx).Errorf("Failed to save %s feed snapshot: %s.", s.feed.Name(), err)
+	}
+}
@@ -0,0 +1,144 @@
+package scraper
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/samber/mo"
+)
+
+const (
+	statusDurationHistory = 30
+	statusErrorHistory    = 5
+)
+
+type FeedStatus struct {
+	Name         string
+	Parametrized bool
+
+	LastScrape time.Time
+	Duration   time.Duration
+	Status     string
+	Items      int
+
+	LastSuccess  time.Time
+	LastFailure  time.Time
+	LastError    string
+	FailingSince time.Time
+	ErrorsInARow int
+
+	// Recent scrape durations and errors from the oldest to the newest
+	Durations    []time.Duration
+	RecentErrors []FeedError
+}
+
+type FeedError struct {
+	Time  time.Time
+	Error string
+}
+
+type statusTracker struct {
+	lock   sync.Mutex
+	status FeedStatus
+}
+
+func newStatusTracker(name string, parametrized bool) *statusTracker {
+	return &statusTracker{
+		status: FeedStatus{
+			Name:         name,
+			Parametrized: parametrized,
+		},
+	}
+}
+
+func (t *statusTracker) observe(startTime time.Time, duration time.Duration, status string, result ScrapeResult) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := &t.status
+	s.LastScrape = startTime
+	s.Duration = duration
+	s.Status = status
+	s.Durations = appendLimited(s.Durations, duration, statusDurationHistory)
+
+	if result.HTTPStatus == http.StatusOK {
+		s.LastSuccess = startTime
+		s.FailingSince = time.Time{}
+		s.ErrorsInARow = 0
+		if result.feed != nil {
+			s.Items = len(result.feed.Items)
+		}
+	} else {
+		if s.ErrorsInARow == 0 {
+			s.FailingSince = startTime
+		}
+		s.LastFailure = startTime
+		if result.Error != nil {
+			s.LastError = result.Error.Error()
+			s.RecentErrors = appendLimited(s.RecentErrors, FeedError{
+				Time:  startTime,
+				Error: s.LastError,
+			}, statusErrorHistory)
+		}
+		s.ErrorsInARow++
+	}
+}
+
+// Seeds the status with the feed state restored from the snapshot. Status and duration of the last scrape are unknown.
+func (t *statusTracker) restore(
+	lastScrape time.Time, lastSuccess mo.Option[ScrapeResult], lastError mo.Option[ScrapeResult],
+	failingSince mo.Option[time.Time],
+) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := &t.status
+	s.LastScrape = lastScrape
+
+	if lastSuccess, ok := lastSuccess.Get(); ok {
+		s.LastSuccess = lastSuccess.Time
+		if lastSuccess.feed != nil {
+			s.Items = len(lastSuccess.feed.Items)
+		}
+	}
+
+	if lastError, ok := lastError.Get(); ok {
+		s.LastFailure = lastError.Time
+		s.FailingSince = failingSince.OrElse(lastError.Time)
+		s.ErrorsInARow = 1
+		if lastError.Error != nil {
+			s.LastError = lastError.Error.Error()
+			s.RecentErrors = []FeedError{{Time: lastError.Time, Error: s.LastError}}
+		}
+	}
+}
+
+// Takes over the status of the feed which is being replaced
+func (t *statusTracker) inherit(other *statusTracker) {
+	status := other.get()
+	status.Name = t.status.Name
+	status.Parametrized = t.status.Parametrized
+
+	t.lock.Lock()
+	t.status = status
+	t.lock.Unlock()
+}
+
+func (t *statusTracker) get() FeedStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	status := t.status
+	status.Durations = slices.Clone(status.Durations)
+	status.RecentErrors = slices.Clone(status.RecentErrors)
+	return status
+}
+
+func appendLimited[T any](values []T, value T, limit int) []T {
+	if len(values) >= limit {
+		values = slices.Delete(values, 0, len(values)-limit+1)
+	}
+	return append(values, value)
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+type WebSubConfig struct {
+	// Public URL of the feeds server which is used to build topic URLs of the feeds
+	BaseURL *url.URL
+
+	// The hub which is advertised in the feeds
+	Hub *url.URL
+
+	// Notified when a background feed changes
+	Publisher Publisher
+}
+
+// Publisher delivers feed updates to WebSub subscribers either directly or through an external hub
+type Publisher interface {
+	Publish(ctx context.Context, topic string, contentType string, content []byte) error
+}
+
+// WebSub discovery links of a published feed
+type feedLinks struct {
+	hub    string
+	topics map[Format]string
+}
+
+func makeFeedLinks(config WebSubConfig, name string) feedLinks {
+	links := feedLinks{
+		hub:    config.Hub.String(),
+		topics: make(map[Format]string, len(Formats)),
+	}
+	for _, format := range Formats {
+		links.topics[format] = config.BaseURL.JoinPath(FeedPath(name, format)).String()
+	}
+	return links
+}
+
+// Returns a shallow copy of the feed with the links for the specified format. Links of the source feed are always
+// dropped, since they point to the source, not to us.
+func withLinks(feed *rss.Feed, links mo.Option[feedLinks], format Format) *rss.Feed {
+	result := *feed
+	result.AtomLinks = nil
+
+	if links, ok := links.Get(); ok {
+		result.AtomLinks = []*rss.AtomLink{
+			{Rel: rss.LinkRelSelf, Href: links.topics[format]},
+			{Rel: rss.LinkRelHub, Href: links.hub},
+		}
+	}
+
+	return &result
+}
+
+// Notifies WebSub subscribers about the feed update
+func (s *BackgroundScraper) publish(ctx context.Context, result ScrapeResult) {
+	config, ok := s.webSub.Get()
+	if !ok {
+		return
+	}
+
+	links := s.links.MustGet()
+	for _, format := range Formats {
+		formatted := result.Format(format)
+		if err := config.Publisher.Publish(ctx, links.topics[format], formatted.ContentType, formatted.Data); err != nil {
+			logging.L(ctx).Errorf("Failed to publish %s feed update via WebSub: %s.", s.feed.Name(), err)
+		}
+	}
+}
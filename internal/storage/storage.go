@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Storage is a simple directory-based storage which stores each value as a separate JSON file.
+type Storage struct {
+	path string
+}
+
+func New(path string) (*Storage, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create %q storage directory: %w", path, err)
+	}
+	return &Storage{path: path}, nil
+}
+
+func (s *Storage) Load(key string, value any) (bool, error) {
+	path := s.getPath(key)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to load %q: %w", path, err)
+	}
+
+	return true, nil
+}
+
+func (s *Storage) Save(key string, value any) (retErr error) {
+	path := s.getPath(key)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.path, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	} else if err := file.Sync(); err != nil {
+		return err
+	} else if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *Storage) Delete(key string) error {
+	if err := os.Remove(s.getPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) getPath(key string) string {
+	// Feed names may contain arbitrary parameters, so escape them to get a valid file name
+	return filepath.Join(s.path, url.PathEscape(key)+".json")
+}
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"context"
 	"errors"
+	"net"
 )
 
 type Temporary interface {
@@ -16,3 +18,78 @@ func IsTemporaryError(err error) bool {
 	}
 	return false
 }
+
+const (
+	ErrorCategoryNetwork          = "network"
+	ErrorCategoryTimeout          = "timeout"
+	ErrorCategoryHTTP4xx          = "http_4xx"
+	ErrorCategoryHTTP5xx          = "http_5xx"
+	ErrorCategoryHTTPOther        = "http_other"
+	ErrorCategoryContentType      = "content_type"
+	ErrorCategoryParse            = "parse"
+	ErrorCategorySelectorNotFound = "selector_not_found"
+	ErrorCategoryBrowser          = "browser"
+	ErrorCategoryRender           = "render"
+	ErrorCategoryOther            = "other"
+)
+
+// Categorized is implemented by errors which know their category. Feed generators may implement it for their own
+// errors as well: the interface is matched by method, so they don't need to import this package.
+type Categorized interface {
+	ErrorCategory() string
+}
+
+// GetErrorCategory returns the category of the outermost categorized error in the chain
+func GetErrorCategory(err error) string {
+	if category, ok := getErrorCategory(err); ok {
+		return category
+	}
+	return ErrorCategoryOther
+}
+
+// HasErrorCategory returns true if any error in the chain is categorized
+func HasErrorCategory(err error) bool {
+	_, ok := getErrorCategory(err)
+	return ok
+}
+
+func getErrorCategory(err error) (string, bool) {
+	var categorized Categorized
+	if errors.As(err, &categorized) {
+		return categorized.ErrorCategory(), true
+	}
+	return "", false
+}
+
+type categorizedError struct {
+	error    error
+	category string
+}
+
+var _ Categorized = categorizedError{}
+
+func WithErrorCategory(err error, category string) error {
+	return categorizedError{error: err, category: category}
+}
+
+// NetworkError categorizes the network error as timeout or generic network error
+func NetworkError(err error) error {
+	var netErr net.Error
+	category := ErrorCategoryNetwork
+	if errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+		category = ErrorCategoryTimeout
+	}
+	return WithErrorCategory(err, category)
+}
+
+func (e categorizedError) ErrorCategory() string {
+	return e.category
+}
+
+func (e categorizedError) Error() string {
+	return e.error.Error()
+}
+
+func (e categorizedError) Unwrap() error {
+	return e.error
+}
@@ -0,0 +1,464 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+)
+
+const (
+	defaultLease = 10 * 24 * time.Hour
+	maxLease     = 30 * 24 * time.Hour
+	maxSecretLen = 200
+
+	maxSubscriptions = 10000
+
+	// Every verification is an outbound request to a URL chosen by an anonymous client
+	maxVerifications     = 100
+	maxHostVerifications = 5
+
+	deliveryWorkers     = 20
+	maxQueuedDeliveries = maxSubscriptions
+)
+
+var (
+	errTooManySubscriptions = errors.New("too many subscriptions")
+	errTooManyVerifications = errors.New("too many pending verifications")
+)
+
+// Hub is a minimal in-memory WebSub hub which accepts subscriptions to the topics under the specified base URL and
+// pushes their content to the subscribers on publishing.
+//
+// The hub is exposed to anonymous clients, so it refuses to send requests to non-public addresses and limits the
+// number of subscriptions and pending verifications.
+type Hub struct {
+	url           *url.URL
+	topics        string
+	client        *http.Client
+	lock          sync.Mutex
+	subscriptions map[subscriptionKey]*subscription
+
+	// Pending verifications: total and per callback host
+	verifications        sync.WaitGroup
+	pendingVerifications int
+	pendingHosts         map[string]int
+
+	// Content deliveries are made asynchronously by a limited number of workers
+	deliveryLock    sync.Mutex
+	deliveryQueue   []*pendingDelivery
+	deliveryWorkers int
+	deliveries      sync.WaitGroup
+
+	// Allows callbacks on private addresses (for tests)
+	allowPrivateCallbacks bool
+}
+
+type subscriptionKey struct {
+	topic    string
+	callback string
+}
+
+type subscription struct {
+	subscriptionKey
+	secret  string
+	expires time.Time
+}
+
+type pendingDelivery struct {
+	ctx          context.Context
+	subscription *subscription
+	contentType  string
+	content      []byte
+}
+
+func NewHub(hubURL *url.URL, baseURL *url.URL) *Hub {
+	hub := &Hub{
+		url:           hubURL,
+		topics:        strings.TrimSuffix(baseURL.String(), "/") + "/",
+		subscriptions: make(map[subscriptionKey]*subscription),
+		pendingHosts:  make(map[string]int),
+	}
+
+	// Callback host names may resolve to any address, so check the addresses we actually connect to
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(_ string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			} else if !hub.isAllowedAddress(addrPort.Addr()) {
+				return fmt.Errorf("%s is not a public address", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	hub.client = &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+	}
+
+	return hub
+}
+
+// ServeHTTP handles subscription requests. The subscriber's intent is verified asynchronously as required by the spec.
+func (h *Hub) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+
+	mode, subscription, err := h.parseRequest(request)
+	if err != nil {
+		logging.L(ctx).Warnf("Invalid WebSub request: %s.", err)
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if mode == modeSubscribe && !h.canSubscribe(subscription.subscriptionKey) {
+		logging.L(ctx).Warnf("Rejecting %s WebSub subscription: %s.", subscription.callback, errTooManySubscriptions)
+		http.Error(writer, errTooManySubscriptions.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	host, ok := h.startVerification(subscription.callback)
+	if !ok {
+		logging.L(ctx).Warnf("Rejecting %s WebSub %s request: %s.", subscription.callback, mode, errTooManyVerifications)
+		http.Error(writer, errTooManyVerifications.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+
+	h.verifications.Go(func() {
+		defer h.finishVerification(host)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requestTimeout)
+		defer cancel()
+		h.verify(ctx, mode, subscription)
+	})
+}
+
+// Reserves a slot for the verification request, so the hub can't be used to flood third-party hosts
+func (h *Hub) startVerification(callback string) (string, bool) {
+	parsed, err := url.Parse(callback)
+	if err != nil {
+		return "", false
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.pendingVerifications >= maxVerifications || h.pendingHosts[host] >= maxHostVerifications {
+		return "", false
+	}
+	h.pendingVerifications++
+	h.pendingHosts[host]++
+
+	return host, true
+}
+
+func (h *Hub) finishVerification(host string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.pendingVerifications--
+	h.pendingHosts[host]--
+	if h.pendingHosts[host] == 0 {
+		delete(h.pendingHosts, host)
+	}
+}
+
+func (h *Hub) parseRequest(request *http.Request) (string, *subscription, error) {
+	if err := request.ParseForm(); err != nil {
+		return "", nil, err
+	}
+
+	mode := request.PostForm.Get("hub.mode")
+	if mode != modeSubscribe && mode != modeUnsubscribe {
+		return "", nil, fmt.Errorf("unsupported mode: %q", mode)
+	}
+
+	topic := request.PostForm.Get("hub.topic")
+	if !strings.HasPrefix(topic, h.topics) {
+		return "", nil, fmt.Errorf("unknown topic: %q", topic)
+	}
+
+	callback := request.PostForm.Get("hub.callback")
+	if !h.isValidCallback(callback) {
+		return "", nil, fmt.Errorf("invalid callback URL: %q", callback)
+	}
+
+	secret := request.PostForm.Get("hub.secret")
+	if len(secret) >= maxSecretLen {
+		return "", nil, errors.New("the secret is too long")
+	}
+
+	lease := defaultLease
+	if value := request.PostForm.Get("hub.lease_seconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return "", nil, fmt.Errorf("invalid lease: %q", value)
+		}
+		lease = min(time.Duration(seconds)*time.Second, maxLease)
+	}
+
+	return mode, &subscription{
+		subscriptionKey: subscriptionKey{topic: topic, callback: callback},
+		secret:          secret,
+		expires:         time.Now().Add(lease),
+	}, nil
+}
+
+func (h *Hub) isValidCallback(callback string) bool {
+	parsed, err := url.Parse(callback)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return false
+	}
+
+	host := parsed.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return h.isAllowedAddress(addr)
+	}
+	return h.allowPrivateCallbacks || (host != "localhost" && !strings.HasSuffix(host, ".localhost"))
+}
+
+// Rejects loopback, link-local, private and other special addresses, so the hub can't be used to reach internal
+// services
+func (h *Hub) isAllowedAddress(addr netip.Addr) bool {
+	if h.allowPrivateCallbacks {
+		return true
+	}
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+func (h *Hub) canSubscribe(key subscriptionKey) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.canSubscribeLocked(key)
+}
+
+func (h *Hub) canSubscribeLocked(key subscriptionKey) bool {
+	_, exists := h.subscriptions[key]
+	return exists || len(h.subscriptions) < maxSubscriptions
+}
+
+// Verifies the subscriber's intent by sending it a challenge which it must echo back
+func (h *Hub) verify(ctx context.Context, mode string, subscription *subscription) {
+	challenge := rand.Text()
+
+	query := url.Values{
+		"hub.mode":      {mode},
+		"hub.topic":     {subscription.topic},
+		"hub.challenge": {challenge},
+	}
+	if mode == modeSubscribe {
+		query.Set("hub.lease_seconds", strconv.Itoa(int(time.Until(subscription.expires).Seconds())))
+	}
+
+	callback, err := url.Parse(subscription.callback)
+	if err != nil {
+		logging.L(ctx).Errorf("Failed to verify %s WebSub subscriber: %s.", subscription.callback, err)
+		return
+	}
+	for name, values := range callback.Query() {
+		query[name] = append(query[name], values...)
+	}
+	callback.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, callback.String(), nil)
+	if err != nil {
+		logging.L(ctx).Errorf("Failed to verify %s WebSub subscriber: %s.", subscription.callback, err)
+		return
+	}
+
+	status, body, err := send(ctx, h.client, request)
+	if err != nil {
+		logging.L(ctx).Warnf("Failed to verify %s WebSub subscriber: %s.", subscription.callback, err)
+		return
+	} else if !isSuccess(status) || string(body) != challenge {
+		logging.L(ctx).Warnf("%s WebSub subscriber hasn't confirmed its %s request.", subscription.callback, mode)
+		return
+	}
+
+	// The limit might have been reached while we were verifying the intent
+	if err := h.update(mode, subscription); err != nil {
+		logging.L(ctx).Warnf("Rejecting %s WebSub subscription: %s.", subscription.callback, err)
+		return
+	}
+
+	logging.L(ctx).Infof("%s WebSub subscriber has confirmed its %s request to %s.",
+		subscription.callback, mode, subscription.topic)
+}
+
+func (h *Hub) update(mode string, subscription *subscription) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key := subscription.subscriptionKey
+	if mode == modeUnsubscribe {
+		delete(h.subscriptions, key)
+		return nil
+	}
+
+	if !h.canSubscribeLocked(key) {
+		return errTooManySubscriptions
+	}
+	h.subscriptions[key] = subscription
+
+	return nil
+}
+
+// Publish queues delivery of the topic content to all its subscribers. The deliveries are made asynchronously, so
+// slow subscribers don't delay the publisher.
+func (h *Hub) Publish(ctx context.Context, topic string, contentType string, content []byte) error {
+	subscriptions := h.getSubscriptions(topic)
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	h.deliveryLock.Lock()
+	defer h.deliveryLock.Unlock()
+
+	queued := 0
+	for _, subscription := range subscriptions {
+		if len(h.deliveryQueue) >= maxQueuedDeliveries {
+			break
+		}
+
+		h.deliveryQueue = append(h.deliveryQueue, &pendingDelivery{
+			ctx:          ctx,
+			subscription: subscription,
+			contentType:  contentType,
+			content:      content,
+		})
+		queued++
+
+		if h.deliveryWorkers < deliveryWorkers {
+			h.deliveryWorkers++
+			h.deliveries.Go(h.deliver)
+		}
+	}
+
+	logging.L(ctx).Debugf("%s update has been queued for delivery to %d WebSub subscribers.", topic, queued)
+	if dropped := len(subscriptions) - queued; dropped != 0 {
+		return fmt.Errorf("the delivery queue is full: %d subscribers won't receive the update", dropped)
+	}
+
+	return nil
+}
+
+// Processes the delivery queue until it becomes empty
+func (h *Hub) deliver() {
+	for {
+		delivery, ok := h.getDelivery()
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(delivery.ctx, requestTimeout)
+		err := h.push(ctx, delivery.subscription, delivery.contentType, delivery.content)
+		cancel()
+
+		if err != nil {
+			logging.L(ctx).Warnf("Failed to push %s update to %s WebSub subscriber: %s.",
+				delivery.subscription.topic, delivery.subscription.callback, err)
+		}
+	}
+}
+
+func (h *Hub) getDelivery() (*pendingDelivery, bool) {
+	h.deliveryLock.Lock()
+	defer h.deliveryLock.Unlock()
+
+	if len(h.deliveryQueue) == 0 {
+		h.deliveryWorkers--
+		return nil, false
+	}
+
+	delivery := h.deliveryQueue[0]
+	h.deliveryQueue[0] = nil
+	h.deliveryQueue = h.deliveryQueue[1:]
+
+	return delivery, true
+}
+
+// Returns active subscriptions to the topic, dropping the expired ones
+func (h *Hub) getSubscriptions(topic string) []*subscription {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var (
+		now           = time.Now()
+		subscriptions []*subscription
+	)
+
+	for key, subscription := range h.subscriptions {
+		if now.After(subscription.expires) {
+			delete(h.subscriptions, key)
+		} else if subscription.topic == topic {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	return subscriptions
+}
+
+func (h *Hub) push(ctx context.Context, subscription *subscription, contentType string, content []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.callback, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Add("Link", fmt.Sprintf(`<%s>; rel="hub"`, h.url))
+	request.Header.Add("Link", fmt.Sprintf(`<%s>; rel="self"`, subscription.topic))
+	if subscription.secret != "" {
+		signature := hmac.New(sha256.New, []byte(subscription.secret))
+		signature.Write(content)
+		request.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(signature.Sum(nil)))
+	}
+
+	status, _, err := send(ctx, h.client, request)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isSuccess(status):
+		return nil
+
+	// The subscriber tells us that the subscription no longer exists
+	case status == http.StatusGone:
+		h.lock.Lock()
+		if h.subscriptions[subscription.subscriptionKey] == subscription {
+			delete(h.subscriptions, subscription.subscriptionKey)
+		}
+		h.lock.Unlock()
+		return errors.New("the subscription has been deleted by the subscriber")
+
+	default:
+		return fmt.Errorf("the subscriber has rejected the content: %s", http.StatusText(status))
+	}
+}
@@ -0,0 +1,294 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+)
+
+type delivery struct {
+	header http.Header
+	body   string
+}
+
+func TestHub(t *testing.T) {
+	t.Parallel()
+
+	const (
+		topic  = "https://feeds.example.com/test.rss"
+		secret = "some-secret"
+	)
+
+	ctx := testutil.Context(t)
+	verified := make(chan url.Values, 1)
+	deliveries := make(chan delivery, 1)
+
+	subscriber := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			query := request.URL.Query()
+			_, _ = writer.Write([]byte(query.Get("hub.challenge")))
+			verified <- query
+
+		case http.MethodPost:
+			body, err := io.ReadAll(request.Body)
+			if err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			deliveries <- delivery{header: request.Header, body: string(body)}
+		}
+	}))
+	defer subscriber.Close()
+
+	hubURL, baseURL := mustParseURL(t, "https://feeds.example.com/websub"), mustParseURL(t, "https://feeds.example.com/")
+	hub := NewHub(hubURL, baseURL)
+	hub.allowPrivateCallbacks = true
+
+	hubServer := newHubServer(t, hub)
+	defer hubServer.Close()
+
+	request := func(mode string, topic string) int {
+		response, err := http.PostForm(hubServer.URL, url.Values{
+			"hub.mode":     {mode},
+			"hub.topic":    {topic},
+			"hub.callback": {subscriber.URL + "/callback?id=1"},
+			"hub.secret":   {secret},
+		})
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		return response.StatusCode
+	}
+
+	require.Equal(t, http.StatusBadRequest, request(modeSubscribe, "https://other.example.com/test.rss"))
+
+	// Subscribe and check the intent verification
+	require.Equal(t, http.StatusAccepted, request(modeSubscribe, topic))
+	query := <-verified
+	require.Equal(t, modeSubscribe, query.Get("hub.mode"))
+	require.Equal(t, topic, query.Get("hub.topic"))
+	require.Equal(t, "1", query.Get("id"))
+	require.NotEmpty(t, query.Get("hub.lease_seconds"))
+
+	require.Eventually(t, func() bool {
+		return len(hub.getSubscriptions(topic)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Check the content distribution
+	content := "<rss>Updated</rss>"
+	require.NoError(t, hub.Publish(ctx, topic, "application/rss+xml", []byte(content)))
+
+	pushed := <-deliveries
+	require.Equal(t, content, pushed.body)
+	require.Equal(t, "application/rss+xml", pushed.header.Get("Content-Type"))
+	require.Equal(t, []string{
+		`<https://feeds.example.com/websub>; rel="hub"`,
+		`<https://feeds.example.com/test.rss>; rel="self"`,
+	}, pushed.header.Values("Link"))
+
+	signature := hmac.New(sha256.New, []byte(secret))
+	signature.Write([]byte(content))
+	require.Equal(t, "sha256="+hex.EncodeToString(signature.Sum(nil)), pushed.header.Get("X-Hub-Signature"))
+
+	// Other topics must not be delivered to the subscriber
+	require.NoError(t, hub.Publish(ctx, strings.Replace(topic, ".rss", ".atom", 1), "application/atom+xml", nil))
+	require.Empty(t, deliveries)
+
+	// Unsubscribe
+	require.Equal(t, http.StatusAccepted, request(modeUnsubscribe, topic))
+	require.Equal(t, modeUnsubscribe, (<-verified).Get("hub.mode"))
+	require.Eventually(t, func() bool {
+		return len(hub.getSubscriptions(topic)) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	hub.verifications.Wait()
+	hub.deliveries.Wait()
+}
+
+func TestHubLimits(t *testing.T) {
+	t.Parallel()
+
+	const topic = "https://feeds.example.com/test.rss"
+
+	hub := NewHub(mustParseURL(t, "https://feeds.example.com/websub"), mustParseURL(t, "https://feeds.example.com/"))
+	hubServer := newHubServer(t, hub)
+	defer hubServer.Close()
+
+	request := func(callback string) int {
+		response, err := http.PostForm(hubServer.URL, url.Values{
+			"hub.mode":     {modeSubscribe},
+			"hub.topic":    {topic},
+			"hub.callback": {callback},
+		})
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		return response.StatusCode
+	}
+
+	for _, callback := range []string{
+		"http://localhost/callback",
+		"http://127.0.0.1/callback",
+		"http://[::1]/callback",
+		"http://[::ffff:127.0.0.1]/callback",
+		"http://10.1.2.3/callback",
+		"http://192.168.0.1/callback",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/callback",
+	} {
+		require.Equal(t, http.StatusBadRequest, request(callback), callback)
+	}
+
+	hub.lock.Lock()
+	for id := range maxSubscriptions {
+		key := subscriptionKey{topic: topic, callback: fmt.Sprintf("https://subscriber.example.com/%d", id)}
+		hub.subscriptions[key] = &subscription{subscriptionKey: key, expires: time.Now().Add(time.Hour)}
+	}
+	hub.lock.Unlock()
+
+	require.Equal(t, http.StatusServiceUnavailable, request("https://other.example.com/callback"))
+}
+
+func TestHubVerificationLimits(t *testing.T) {
+	t.Parallel()
+
+	const topic = "https://feeds.example.com/test.rss"
+
+	// The subscriber doesn't respond until released, so the verifications remain pending
+	release := make(chan struct{})
+	subscriber := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer subscriber.Close()
+
+	hub := NewHub(mustParseURL(t, "https://feeds.example.com/websub"), mustParseURL(t, "https://feeds.example.com/"))
+	hub.allowPrivateCallbacks = true
+
+	hubServer := newHubServer(t, hub)
+	defer hubServer.Close()
+
+	request := func(callback string) int {
+		response, err := http.PostForm(hubServer.URL, url.Values{
+			"hub.mode":     {modeSubscribe},
+			"hub.topic":    {topic},
+			"hub.callback": {callback},
+		})
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		return response.StatusCode
+	}
+
+	for id := range maxHostVerifications {
+		require.Equal(t, http.StatusAccepted, request(fmt.Sprintf("%s/%d", subscriber.URL, id)))
+	}
+	require.Equal(t, http.StatusServiceUnavailable, request(subscriber.URL+"/other"))
+
+	// Other hosts are limited only by the total number of pending verifications
+	otherHost := strings.Replace(subscriber.URL, "127.0.0.1", "localhost", 1)
+	require.Equal(t, http.StatusAccepted, request(otherHost+"/callback"))
+
+	hub.lock.Lock()
+	pending := hub.pendingVerifications
+	hub.pendingVerifications = maxVerifications
+	hub.lock.Unlock()
+
+	require.Equal(t, http.StatusServiceUnavailable, request(otherHost+"/other"))
+
+	hub.lock.Lock()
+	hub.pendingVerifications = pending
+	hub.lock.Unlock()
+
+	close(release)
+	hub.verifications.Wait()
+
+	require.Zero(t, hub.pendingVerifications)
+	require.Empty(t, hub.pendingHosts)
+	require.Equal(t, http.StatusAccepted, request(subscriber.URL+"/other"))
+	hub.verifications.Wait()
+}
+
+func TestHubDeliveryQueue(t *testing.T) {
+	t.Parallel()
+
+	const (
+		topic       = "https://feeds.example.com/test.rss"
+		subscribers = 2 * deliveryWorkers
+	)
+
+	ctx := testutil.Context(t)
+
+	// The subscriber doesn't respond until released, so the deliveries remain in progress
+	var delivered atomic.Int64
+	release := make(chan struct{})
+	subscriber := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+		delivered.Add(1)
+	}))
+	defer subscriber.Close()
+
+	hub := NewHub(mustParseURL(t, "https://feeds.example.com/websub"), mustParseURL(t, "https://feeds.example.com/"))
+	hub.allowPrivateCallbacks = true
+
+	for id := range subscribers {
+		key := subscriptionKey{topic: topic, callback: fmt.Sprintf("%s/%d", subscriber.URL, id)}
+		hub.subscriptions[key] = &subscription{subscriptionKey: key, expires: time.Now().Add(time.Hour)}
+	}
+
+	// Publishing doesn't wait for the subscribers
+	require.NoError(t, hub.Publish(ctx, topic, "application/rss+xml", []byte("<rss/>")))
+	require.Eventually(t, func() bool {
+		hub.deliveryLock.Lock()
+		defer hub.deliveryLock.Unlock()
+		return len(hub.deliveryQueue) == subscribers-deliveryWorkers
+	}, time.Second, time.Millisecond)
+
+	hub.deliveryLock.Lock()
+	require.Equal(t, deliveryWorkers, hub.deliveryWorkers)
+	queue := hub.deliveryQueue
+	for len(hub.deliveryQueue) < maxQueuedDeliveries {
+		hub.deliveryQueue = append(hub.deliveryQueue, queue[0])
+	}
+	hub.deliveryLock.Unlock()
+
+	// The updates are dropped when the queue is full
+	require.Error(t, hub.Publish(ctx, topic, "application/rss+xml", []byte("<rss/>")))
+
+	hub.deliveryLock.Lock()
+	hub.deliveryQueue = queue
+	hub.deliveryLock.Unlock()
+
+	close(release)
+	hub.deliveries.Wait()
+
+	require.Equal(t, int64(subscribers), delivered.Load())
+	require.Zero(t, hub.deliveryWorkers)
+}
+
+func newHubServer(t *testing.T, hub *Hub) *httptest.Server {
+	server := httptest.NewUnstartedServer(hub)
+	server.Config.BaseContext = func(net.Listener) context.Context {
+		return testutil.Context(t)
+	}
+	server.Start()
+	return server
+}
+
+func mustParseURL(t *testing.T, value string) *url.URL {
+	parsed, err := url.Parse(value)
+	require.NoError(t, err)
+	return parsed
+}
@@ -0,0 +1,44 @@
+package websub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Notifier notifies an external hub about topic updates, so the hub fetches the new content and distributes it itself
+type Notifier struct {
+	hub    *url.URL
+	client *http.Client
+}
+
+func NewNotifier(hub *url.URL) *Notifier {
+	return &Notifier{
+		hub:    hub,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (n *Notifier) Publish(ctx context.Context, topic string, _ string, _ []byte) error {
+	form := url.Values{
+		"hub.mode": {modePublish},
+		"hub.url":  {topic},
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.hub.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	status, _, err := send(ctx, n.client, request)
+	if err != nil {
+		return err
+	} else if !isSuccess(status) {
+		return fmt.Errorf("%s hub has rejected the notification: %s", n.hub, http.StatusText(status))
+	}
+
+	return nil
+}
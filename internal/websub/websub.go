@@ -0,0 +1,47 @@
+// Package websub implements WebSub (https://www.w3.org/TR/websub/) publishing: notification of external hubs and a
+// minimal built-in hub.
+package websub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+)
+
+const (
+	modeSubscribe   = "subscribe"
+	modeUnsubscribe = "unsubscribe"
+	modePublish     = "publish"
+)
+
+const (
+	requestTimeout  = 30 * time.Second
+	maxResponseSize = 64 * 1024
+)
+
+// Sends the request and returns the response status code and the beginning of its body
+func send(ctx context.Context, client *http.Client, request *http.Request) (int, []byte, error) {
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			logging.L(ctx).Errorf("Failed to close HTTP client body: %s.", err)
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return response.StatusCode, body, nil
+}
+
+func isSuccess(status int) bool {
+	return status >= 200 && status < 300
+}
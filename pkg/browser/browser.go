@@ -25,11 +25,14 @@ import (
 	"github.com/chromedp/cdproto/emulation"
 	"github.com/chromedp/chromedp"
 	"github.com/samber/mo"
+	"go.opentelemetry.io/otel"
 
 	"github.com/KonishchevDmitry/feedsd/internal/util"
 	"github.com/KonishchevDmitry/feedsd/pkg/rss"
 )
 
+var tracer = otel.Tracer("github.com/KonishchevDmitry/feedsd/pkg/browser")
+
 const (
 	screenWidth, screenHeight     = 1728, 1117
 	viewportWidth, viewportHeight = 1664, 992
@@ -195,29 +198,29 @@ func Get(ctx context.Context, url *url.URL, opts ...QueryOption) (*Response, err
 		})
 	}
 
-	actions = append(actions,
+	actions = append(actions, tracedAction("browser.navigate",
 		chromedp.Navigate(url.String()),
 		chromedp.WaitVisible("body", chromedp.ByQuery),
-	)
+	))
 
 	if duration := options.sleep; duration != 0 {
-		actions = append(actions,
+		actions = append(actions, tracedAction("browser.sleep",
 			chromedp.Sleep(duration),
-			chromedp.WaitVisible("body", chromedp.ByQuery))
+			chromedp.WaitVisible("body", chromedp.ByQuery)))
 	}
 
 	var body, html string
-	actions = append(actions,
+	actions = append(actions, tracedAction("browser.extract",
 		// At this time this simple method of getting non-HTML body works good for us, but if there will be some problems
 		// in the future, we should try more complex method:
 		// https://github.com/chromedp/examples/blob/a363975bc138e67550290738cbf046ae10056c7c/download_image/main.go
 		chromedp.Evaluate("document.body.innerText", &body),
 		chromedp.OuterHTML("html", &html),
-	)
+	))
 
 	var screenshot []byte
 	if options.screenshot.IsSome() {
-		actions = append(actions, chromedp.FullScreenshot(&screenshot, 100))
+		actions = append(actions, tracedAction("browser.screenshot", chromedp.FullScreenshot(&screenshot, 100)))
 	}
 
 	response, err := chromedp.RunResponse(ctx, actions...)
@@ -273,6 +276,16 @@ func Get(ctx context.Context, url *url.URL, opts ...QueryOption) (*Response, err
 	return result, nil
 }
 
+// Wraps the actions into a span, so the time spent on each navigation step is visible in traces
+func tracedAction(name string, actions ...chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		ctx, span := tracer.Start(ctx, name)
+		err := chromedp.Tasks(actions).Do(ctx)
+		util.EndSpan(span, err)
+		return err
+	})
+}
+
 func configureExecAllocator(
 	ctx context.Context, options options, execOptions ...chromedp.ExecAllocatorOption,
 ) (_ context.Context, _ func(), retErr error) {
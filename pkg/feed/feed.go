@@ -3,8 +3,10 @@ package feed
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/schedule"
 )
 
 type Feed interface {
@@ -12,6 +14,50 @@ type Feed interface {
 	Get(ctx context.Context) (*rss.Feed, error)
 }
 
+// ScheduledFeed may be implemented by background feeds which want to be scraped by their own schedule instead of the
+// default hourly one.
+type ScheduledFeed interface {
+	Feed
+	Schedule() schedule.Schedule
+}
+
+// HistoryFeed may be implemented by background feeds which sources show only a few latest items. Items of such feeds
+// are accumulated across scrapes, so readers which poll the feed rarely don't miss them.
+type HistoryFeed interface {
+	Feed
+	History() History
+}
+
+// History configures item retention of HistoryFeed. Zero values mean no limit.
+type History struct {
+	MaxItems int
+	MaxAge   time.Duration
+}
+
+// Wrapper is implemented by feeds which wrap other feeds
+type Wrapper interface {
+	Unwrap() Feed
+}
+
+// As finds the first feed in the wrapping chain which implements T. It's intended for lookup of optional feed
+// interfaces, which are hidden by wrappers otherwise.
+func As[T any](feed Feed) (T, bool) {
+	for feed != nil {
+		if target, ok := feed.(T); ok {
+			return target, true
+		}
+
+		wrapper, ok := feed.(Wrapper)
+		if !ok {
+			break
+		}
+		feed = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
 type Params interface {
 	Format() string
 }
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+// Merge returns a feed which combines items of the specified feeds. The feeds are scraped in parallel and their items
+// are deduplicated. If some of the feeds fail, the result contains items of the rest ones: the merged feed fails only
+// when all of them fail.
+func Merge(name string, title string, link *url.URL, feeds ...Feed) Feed {
+	return &mergedFeed{
+		name:  name,
+		title: title,
+		link:  link,
+		feeds: feeds,
+	}
+}
+
+type mergedFeed struct {
+	name  string
+	title string
+	link  *url.URL
+	feeds []Feed
+}
+
+func (f *mergedFeed) Name() string {
+	return f.name
+}
+
+func (f *mergedFeed) Get(ctx context.Context) (*rss.Feed, error) {
+	var waitGroup sync.WaitGroup
+
+	results := make([]*rss.Feed, len(f.feeds))
+	errs := make([]error, len(f.feeds))
+
+	for index, feed := range f.feeds {
+		waitGroup.Go(func() {
+			results[index], errs[index] = getMergedFeed(ctx, feed)
+		})
+	}
+	waitGroup.Wait()
+
+	merged := rss.NewFeed(f.title, f.link)
+
+	var failed []error
+	for index, feed := range f.feeds {
+		if err := errs[index]; err != nil {
+			logging.L(ctx).Warnf("Failed to scrape %s feed for %s: %s.", feed.Name(), f.name, err)
+			failed = append(failed, fmt.Errorf("%s: %w", feed.Name(), err))
+			continue
+		}
+
+		result := results[index]
+		result.Normalize()
+		merged.Items = append(merged.Items, result.Items...)
+	}
+
+	if len(f.feeds) != 0 && len(failed) == len(f.feeds) {
+		return nil, fmt.Errorf("all merged feeds have failed: %w", errors.Join(failed...))
+	}
+
+	merged.Deduplicate()
+	return merged, nil
+}
+
+func getMergedFeed(ctx context.Context, feed Feed) (_ *rss.Feed, retErr error) {
+	// The feeds are scraped in separate goroutines, so the panics must be handled here
+	defer func() {
+		if err := recover(); err != nil {
+			stack := debug.Stack()
+			retErr = fmt.Errorf("feed generator has panicked: %v\n%s", err, bytes.TrimRight(stack, "\n"))
+		}
+	}()
+
+	result, err := feed.Get(ctx)
+	if err == nil && result == nil {
+		err = errors.New("feed generator returned no feed")
+	}
+	return result, err
+}
@@ -0,0 +1,71 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	now := time.Now()
+
+	first := &staticFeed{name: "first", items: map[string]time.Time{
+		"a": now.Add(-time.Hour),
+		"b": now.Add(-3 * time.Hour),
+	}}
+	second := &staticFeed{name: "second", items: map[string]time.Time{
+		"b": now.Add(-3 * time.Hour),
+		"c": now.Add(-2 * time.Hour),
+	}}
+	failing := &staticFeed{name: "failing", err: errors.New("some error")}
+
+	getTitles := func(feed Feed) []string {
+		result, err := feed.Get(ctx)
+		require.NoError(t, err)
+
+		var titles []string
+		for _, item := range result.Items {
+			titles = append(titles, item.Title)
+		}
+		return titles
+	}
+
+	link := url.MustParse("https://example.com/")
+	require.Equal(t, []string{"a", "c", "b"}, getTitles(Merge("merged", "Merged", link, first, second)))
+	require.Equal(t, []string{"a", "b"}, getTitles(Merge("merged", "Merged", link, first, failing)))
+
+	_, err := Merge("merged", "Merged", link, failing, failing).Get(ctx)
+	require.ErrorIs(t, err, failing.err)
+}
+
+type staticFeed struct {
+	name  string
+	items map[string]time.Time
+	err   error
+}
+
+func (f *staticFeed) Name() string {
+	return f.name
+}
+
+func (f *staticFeed) Get(ctx context.Context) (*rss.Feed, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	feed := rss.NewFeed(f.name, url.MustParse("https://example.com/"+f.name))
+	for id, date := range f.items {
+		feed.AddItem(date, id, url.MustParse("https://example.com/"+id), "")
+	}
+	return feed, nil
+}
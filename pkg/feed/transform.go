@@ -0,0 +1,241 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/filter"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+)
+
+// Transform post-processes a scraped feed
+type Transform func(feed *rss.Feed)
+
+// Wrap returns a feed which applies the specified transforms to the wrapped feed in the specified order. Optional
+// interfaces of the wrapped feed (ScheduledFeed, HistoryFeed) remain accessible via As().
+func Wrap(feed Feed, transforms ...Transform) Feed {
+	return &wrappedFeed{
+		feed:       feed,
+		transforms: transforms,
+	}
+}
+
+type wrappedFeed struct {
+	feed       Feed
+	transforms []Transform
+}
+
+func (f *wrappedFeed) Name() string {
+	return f.feed.Name()
+}
+
+func (f *wrappedFeed) Get(ctx context.Context) (*rss.Feed, error) {
+	feed, err := f.feed.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transform := range f.transforms {
+		transform(feed)
+	}
+
+	return feed, nil
+}
+
+func (f *wrappedFeed) Unwrap() Feed {
+	return f.feed
+}
+
+// Limit leaves only the specified number of the first items
+func Limit(count int) Transform {
+	if count < 0 {
+		panic(fmt.Sprintf("Invalid item count limit: %d", count))
+	}
+	return func(feed *rss.Feed) {
+		feed.Items = feed.Items[:min(len(feed.Items), count)]
+	}
+}
+
+// MaxAge drops items older than the specified age. Items without date are preserved.
+func MaxAge(maxAge time.Duration) Transform {
+	return func(feed *rss.Feed) {
+		now := time.Now()
+		feed.Filter(func(item *rss.Item) bool {
+			return item.Date.IsZero() || now.Sub(item.Date.Time) <= maxAge
+		})
+	}
+}
+
+// Dedupe drops duplicated items and sorts the rest by date
+func Dedupe() Transform {
+	return func(feed *rss.Feed) {
+		feed.Deduplicate()
+	}
+}
+
+// BlockCategories drops items which have any of the blacklisted categories
+func BlockCategories(blacklist filter.Blacklist) Transform {
+	return func(feed *rss.Feed) {
+		feed.BlockCategories(blacklist)
+	}
+}
+
+func AddCategoriesToDescription() Transform {
+	return func(feed *rss.Feed) {
+		feed.AddCategoriesToDescription()
+	}
+}
+
+func RewriteTitle(rewrite func(title string) string) Transform {
+	return func(feed *rss.Feed) {
+		for _, item := range feed.Items {
+			item.Title = rewrite(item.Title)
+		}
+	}
+}
+
+// SanitizeDescription removes scripts, embedded objects and event handlers from item descriptions
+func SanitizeDescription() Transform {
+	return func(feed *rss.Feed) {
+		for _, item := range feed.Items {
+			item.Description = sanitizeHTML(item.Description)
+		}
+	}
+}
+
+// Elements which are dropped along with their content
+var unsafeElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true, "object": true, "embed": true,
+	"applet": true, "form": true, "input": true, "button": true, "select": true, "textarea": true, "svg": true,
+	"math": true, "template": true, "noscript": true, "noembed": true, "noframes": true, "base": true, "meta": true,
+	"link": true, "title": true, "head": true,
+}
+
+// Elements which are preserved. Other elements are replaced by their content.
+var safeElements = map[string]bool{
+	"a": true, "abbr": true, "audio": true, "b": true, "blockquote": true, "br": true, "caption": true, "cite": true,
+	"code": true, "dd": true, "del": true, "details": true, "div": true, "dl": true, "dt": true, "em": true,
+	"figcaption": true, "figure": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"hr": true, "i": true, "img": true, "ins": true, "kbd": true, "li": true, "mark": true, "ol": true, "p": true,
+	"picture": true, "pre": true, "q": true, "s": true, "small": true, "source": true, "span": true, "strong": true,
+	"sub": true, "summary": true, "sup": true, "table": true, "tbody": true, "td": true, "tfoot": true, "th": true,
+	"thead": true, "tr": true, "u": true, "ul": true, "video": true,
+}
+
+// Attributes which are preserved for any safe element
+var safeAttributes = map[string]bool{
+	"alt": true, "title": true, "width": true, "height": true, "colspan": true, "rowspan": true, "start": true,
+	"datetime": true, "controls": true, "type": true, "lang": true, "dir": true,
+}
+
+// Attributes which contain URLs and are preserved only for the specified elements
+var urlAttributes = map[string][]string{
+	"href":   {"a"},
+	"src":    {"img", "audio", "video", "source"},
+	"poster": {"video"},
+	"cite":   {"blockquote", "q", "del", "ins"},
+}
+
+func sanitizeHTML(data string) string {
+	if data == "" {
+		return data
+	}
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(data))
+	if err != nil {
+		return html.EscapeString(data)
+	}
+
+	body := document.Find("body")
+	if len(body.Nodes) != 1 {
+		return html.EscapeString(data)
+	}
+	sanitizeNodes(body.Nodes[0])
+
+	sanitized, err := body.Html()
+	if err != nil {
+		return html.EscapeString(data)
+	}
+
+	return strings.TrimSpace(sanitized)
+}
+
+// Children are sanitized before their parent, so content of unwrapped elements doesn't need to be revisited
+func sanitizeNodes(parent *html.Node) {
+	for node := parent.FirstChild; node != nil; {
+		next := node.NextSibling
+
+		switch {
+		case node.Type == html.TextNode:
+		case node.Type != html.ElementNode || node.Namespace != "" || unsafeElements[node.Data]:
+			parent.RemoveChild(node)
+		case safeElements[node.Data]:
+			sanitizeNodes(node)
+			node.Attr = slices.DeleteFunc(node.Attr, func(attr html.Attribute) bool {
+				return !isSafeAttribute(node.Data, attr)
+			})
+		default:
+			sanitizeNodes(node)
+			for child := node.FirstChild; child != nil; child = node.FirstChild {
+				node.RemoveChild(child)
+				parent.InsertBefore(child, node)
+			}
+			parent.RemoveChild(node)
+		}
+
+		node = next
+	}
+}
+
+func isSafeAttribute(element string, attr html.Attribute) bool {
+	if attr.Namespace != "" {
+		return false
+	} else if safeAttributes[attr.Key] {
+		return true
+	} else if elements, ok := urlAttributes[attr.Key]; !ok || !slices.Contains(elements, element) {
+		return false
+	}
+
+	switch getURLScheme(attr.Val) {
+	case "", "http", "https", "mailto":
+		return true
+	case "data":
+		return element == "img" && attr.Key == "src"
+	default:
+		return false
+	}
+}
+
+// Returns lowercased URL scheme or an empty string for relative URLs. Strips characters ignored by browsers the same
+// way as they do, so the scheme can't be obfuscated.
+func getURLScheme(value string) string {
+	value = strings.Map(func(char rune) rune {
+		if char == '\t' || char == '\n' || char == '\r' {
+			return -1
+		}
+		return char
+	}, value)
+
+	value = strings.TrimLeftFunc(value, func(char rune) bool {
+		return char <= ' '
+	})
+
+	end := strings.IndexAny(value, ":/?#")
+	if end == -1 || value[end] != ':' {
+		return ""
+	}
+
+	scheme := strings.ToLower(value[:end])
+	if scheme == "" {
+		// Not a valid URL, so don't allow it to be interpreted in any way
+		return ":"
+	}
+
+	return scheme
+}
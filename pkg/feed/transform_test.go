@@ -0,0 +1,119 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/filter"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/schedule"
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+func TestTransforms(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	makeFeed := func() *rss.Feed {
+		feed := rss.NewFeed("Test", url.MustParse("https://example.com/"))
+		for index, title := range []string{"a", "b", "c", "b"} {
+			feed.AddItem(now.Add(-time.Duration(index)*time.Hour), title, url.MustParse("https://example.com/"+title), "")
+		}
+		feed.Items[2].Categories = []string{filter.MakeCategory("News", "Politics")}
+		return feed
+	}
+
+	getTitles := func(transforms ...Transform) []string {
+		feed := makeFeed()
+		for _, transform := range transforms {
+			transform(feed)
+		}
+
+		var titles []string
+		for _, item := range feed.Items {
+			titles = append(titles, item.Title)
+		}
+		return titles
+	}
+
+	require.Equal(t, []string{"a", "b"}, getTitles(Limit(2)))
+	require.Equal(t, []string{"a", "b"}, getTitles(MaxAge(90*time.Minute)))
+	require.Equal(t, []string{"a", "b", "c"}, getTitles(Dedupe()))
+	require.Equal(t, []string{"a", "b", "b"}, getTitles(BlockCategories(filter.Blacklist{"News"})))
+	require.Equal(t, []string{"A", "B", "C", "B"}, getTitles(RewriteTitle(strings.ToUpper)))
+	require.Equal(t, []string{"a", "b"}, getTitles(Dedupe(), Limit(2)))
+	require.Empty(t, getTitles(Limit(0)))
+	require.Panics(t, func() { Limit(-1) })
+}
+
+func TestSanitizeDescription(t *testing.T) {
+	t.Parallel()
+
+	for _, testCase := range []struct {
+		description string
+		expected    string
+	}{
+		{"", ""},
+		{"Plain text", "Plain text"},
+		{`<p onclick="alert(1)">Text<script>alert(2)</script></p>`, "<p>Text</p>"},
+		{`<a href="javascript:alert(1)">Link</a>`, "<a>Link</a>"},
+		{`<a href="https://example.com/">Link</a><img src="data:image/png;base64,AAAA"/>`,
+			`<a href="https://example.com/">Link</a><img src="data:image/png;base64,AAAA"/>`},
+		{`<iframe src="https://example.com/"></iframe><b>Bold</b>`, "<b>Bold</b>"},
+		{`<a href="java&#x09;script:alert(1)">Link</a>`, "<a>Link</a>"},
+		{`<a href="&#x01;javascript:alert(1)">Link</a>`, "<a>Link</a>"},
+		{`<a href=" JaVaScRiPt&colon;alert(1)">Link</a>`, "<a>Link</a>"},
+		{`<a href="vbscript:msgbox(1)">Link</a><a href="data:text/html,test">Link</a>`, "<a>Link</a><a>Link</a>"},
+		{`<a href="/relative" target="_blank" style="color: red">Link</a><a href="mailto:user@example.com">Mail</a>`,
+			`<a href="/relative">Link</a><a href="mailto:user@example.com">Mail</a>`},
+		{`<svg><animate attributeName="href" values="javascript:alert(1)"/><a href="javascript:alert(1)">Link</a></svg>`, ""},
+		{`<font color="red"><center>Text <i>italic</i></center></font><!-- comment -->`, "Text <i>italic</i>"},
+		{`<img src="x" onerror="alert(1)" srcset="javascript:alert(1)"/><video src="data:video/mp4,AAAA"></video>`,
+			`<img src="x"/><video></video>`},
+	} {
+		feed := rss.NewFeed("Test", url.MustParse("https://example.com/"))
+		feed.AddItem(time.Now(), "Test", url.MustParse("https://example.com/item"), testCase.description)
+		SanitizeDescription()(feed)
+		require.Equal(t, testCase.expected, feed.Items[0].Description)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	scheduled := &scheduledFeed{staticFeed: staticFeed{name: "test", items: map[string]time.Time{
+		"a": time.Now(),
+		"b": time.Now().Add(-time.Hour),
+	}}}
+
+	wrapped := Wrap(scheduled, Limit(1))
+	require.Equal(t, "test", wrapped.Name())
+
+	result, err := wrapped.Get(ctx)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+
+	_, ok := wrapped.(ScheduledFeed)
+	require.False(t, ok)
+
+	found, ok := As[ScheduledFeed](wrapped)
+	require.True(t, ok)
+	require.Equal(t, scheduled, found)
+
+	_, ok = As[HistoryFeed](wrapped)
+	require.False(t, ok)
+}
+
+type scheduledFeed struct {
+	staticFeed
+}
+
+func (f *scheduledFeed) Schedule() schedule.Schedule {
+	return schedule.Every(time.Minute)
+}
@@ -0,0 +1,72 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+)
+
+type dumpContextKey struct{}
+
+type dumper struct {
+	path    string
+	counter atomic.Int64
+}
+
+// WithDumpDir makes all fetches within the context dump the fetched documents to the specified directory. It's
+// intended for debugging.
+func WithDumpDir(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, dumpContextKey{}, &dumper{path: path})
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Dumps the response body if it's requested. The body is replaced with its in-memory copy.
+func dumpResponse(ctx context.Context, url *url.URL, response *fetchResult) error {
+	dumper, ok := ctx.Value(dumpContextKey{}).(*dumper)
+	if !ok {
+		return nil
+	}
+
+	data, err := io.ReadAll(&bodyReader{body: response.Body})
+	if err != nil {
+		return err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(data))
+
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(url.Host+url.Path, "_"), "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	extension := ".dump"
+	if mediaType, _, err := mime.ParseMediaType(response.ContentType); err == nil {
+		switch {
+		case mediaType == "text/html":
+			extension = ".html"
+		case strings.HasSuffix(mediaType, "xml"):
+			extension = ".xml"
+		case strings.HasSuffix(mediaType, "json"):
+			extension = ".json"
+		}
+	}
+
+	path := filepath.Join(dumper.path, fmt.Sprintf("%03d-%s%s", dumper.counter.Add(1), name, extension))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		logging.L(ctx).Errorf("Failed to dump %s to %q: %s.", url, path, err)
+	} else {
+		logging.L(ctx).Infof("%s has been dumped to %q.", url, path)
+	}
+
+	return nil
+}
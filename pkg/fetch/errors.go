@@ -1,6 +1,8 @@
 package fetch
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/KonishchevDmitry/feedsd/internal/util"
@@ -22,6 +24,19 @@ func (e *HTTPStatusError) Error() string {
 	return e.message
 }
 
+var _ util.Categorized = &HTTPStatusError{}
+
+func (e *HTTPStatusError) ErrorCategory() string {
+	switch {
+	case e.Status >= 500 && e.Status < 600:
+		return util.ErrorCategoryHTTP5xx
+	case e.Status >= 400 && e.Status < 500:
+		return util.ErrorCategoryHTTP4xx
+	default:
+		return util.ErrorCategoryHTTPOther
+	}
+}
+
 type temporaryError struct {
 	error error
 }
@@ -43,3 +58,11 @@ func (e temporaryError) Error() string {
 func (e temporaryError) Unwrap() error {
 	return e.error
 }
+
+// Browser errors are categorized separately unless they are caused by timeout
+func makeBrowserError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return util.WithErrorCategory(err, util.ErrorCategoryTimeout)
+	}
+	return util.WithErrorCategory(err, util.ErrorCategoryBrowser)
+}
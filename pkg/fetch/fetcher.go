@@ -13,10 +13,17 @@ import (
 	"time"
 
 	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/KonishchevDmitry/feedsd/internal/util"
 	"github.com/KonishchevDmitry/feedsd/pkg/browser"
 )
 
+var tracer = otel.Tracer("github.com/KonishchevDmitry/feedsd/pkg/fetch")
+
 func fetch[T any](
 	ctx context.Context, url *url.URL, allowedMediaTypes []string,
 	parser func(body io.Reader, ignoreCharset bool) (T, error),
@@ -34,6 +41,14 @@ func fetch[T any](
 		opt(&options)
 	}
 
+	ctx, span := tracer.Start(ctx, "fetch", trace.WithAttributes(
+		semconv.URLFull(url.String()),
+		attribute.Bool("fetch.browser", options.emulateBrowser.IsPresent()),
+	))
+	defer func() {
+		util.EndSpan(span, retErr)
+	}()
+
 	ctx, cancel := context.WithTimeout(ctx, time.Minute)
 	defer cancel()
 
@@ -65,6 +80,11 @@ func fetch[T any](
 		}
 	}()
 
+	if err := dumpResponse(ctx, url, response); err != nil {
+		return zero, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+
 	if statusCode := response.StatusCode; statusCode != http.StatusOK {
 		err := error(newHTTPStatusError(statusCode, "the server returned an error: %s", response.StatusText))
 		if statusCode >= 500 && statusCode < 600 {
@@ -74,10 +94,16 @@ func fetch[T any](
 	}
 
 	if err := checkContentType(response.ContentType, allowedMediaTypes); err != nil {
-		return zero, err
+		return zero, util.WithErrorCategory(err, util.ErrorCategoryContentType)
 	}
 
-	return parser(bodyReader{body: response.Body}, ignoreCharset)
+	body := &bodyReader{body: response.Body}
+	result, err := parser(body, ignoreCharset)
+	span.SetAttributes(semconv.HTTPResponseBodySize(body.size))
+	if err != nil && !util.HasErrorCategory(err) {
+		err = util.WithErrorCategory(err, util.ErrorCategoryParse)
+	}
+	return result, err
 }
 
 type fetchResult struct {
@@ -98,7 +124,7 @@ func httpClientFetch(ctx context.Context, url *url.URL) (*fetchResult, error) {
 
 	response, err := client.Do(request) //nolint:bodyclose
 	if err != nil {
-		return nil, makeTemporaryError(err)
+		return nil, makeTemporaryError(util.NetworkError(err))
 	}
 
 	return &fetchResult{
@@ -112,7 +138,7 @@ func httpClientFetch(ctx context.Context, url *url.URL) (*fetchResult, error) {
 func browserFetch(ctx context.Context, url *url.URL, options ...browser.QueryOption) (*fetchResult, error) {
 	response, err := browser.Get(ctx, url, options...)
 	if err != nil {
-		return nil, makeTemporaryError(err)
+		return nil, makeTemporaryError(makeBrowserError(err))
 	}
 
 	return &fetchResult{
@@ -125,14 +151,16 @@ func browserFetch(ctx context.Context, url *url.URL, options ...browser.QueryOpt
 
 type bodyReader struct {
 	body io.Reader
+	size int
 }
 
-var _ io.Reader = bodyReader{}
+var _ io.Reader = &bodyReader{}
 
-func (r bodyReader) Read(buf []byte) (int, error) {
+func (r *bodyReader) Read(buf []byte) (int, error) {
 	n, err := r.body.Read(buf)
+	r.size += n
 	if err != nil && !errors.Is(err, io.EOF) {
-		err = makeTemporaryError(err)
+		err = makeTemporaryError(util.NetworkError(err))
 	}
 	return n, err
 }
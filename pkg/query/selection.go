@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"github.com/KonishchevDmitry/feedsd/internal/util"
 )
 
 func Optional(selection *goquery.Selection, name string, selector string) (*goquery.Selection, bool, error) {
@@ -15,7 +17,7 @@ func Optional(selection *goquery.Selection, name string, selector string) (*goqu
 	case 1:
 		return selection, true, nil
 	default:
-		return nil, false, fmt.Errorf("unable to find %s: got %d elements that match %q selector", name, size, selector)
+		return nil, false, notFoundError("unable to find %s: got %d elements that match %q selector", name, size, selector)
 	}
 }
 
@@ -24,22 +26,27 @@ func One(selection *goquery.Selection, name string, selector string) (*goquery.S
 
 	switch size := selection.Size(); size {
 	case 0:
-		return nil, fmt.Errorf("unable to find %s", name)
+		return nil, notFoundError("unable to find %s", name)
 	case 1:
 		return selection, nil
 	default:
-		return nil, fmt.Errorf("unable to find %s: got %d elements that match %q selector", name, size, selector)
+		return nil, notFoundError("unable to find %s: got %d elements that match %q selector", name, size, selector)
 	}
 }
 
 func Many(selection *goquery.Selection, name string, selector string) (*goquery.Selection, error) {
 	selection = selection.Find(selector)
 	if selection.Size() == 0 {
-		return nil, fmt.Errorf("unable to find %s", name)
+		return nil, notFoundError("unable to find %s", name)
 	}
 	return selection, nil
 }
 
+// Page layout changes usually show up as missing or ambiguous elements, so such errors are categorized separately
+func notFoundError(format string, args ...any) error {
+	return util.WithErrorCategory(fmt.Errorf(format, args...), util.ErrorCategorySelectorNotFound)
+}
+
 func ForEach(selection *goquery.Selection, process func(selection *goquery.Selection) error) error {
 	var err error
 	selection.EachWithBreak(func(i int, selection *goquery.Selection) bool {
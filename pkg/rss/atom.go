@@ -0,0 +1,211 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"time"
+)
+
+const AtomContentType = "application/atom+xml"
+
+type atomFeed struct {
+	XMLName    xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Subtitle   *atomText      `xml:"subtitle"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Logo       string         `xml:"logo,omitempty"`
+	Generator  string         `xml:"generator,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Entries    []atomEntry    `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Length int    `xml:"length,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteAtom writes the feed in Atom format. Atom requires update time for the feed and all its entries, so
+// defaultUpdateTime (the time when the feed has been changed last time for example) is used when the feed has no dates.
+func WriteAtom(feed *Feed, defaultUpdateTime time.Time, writer io.Writer) error {
+	if _, err := writer.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "    ")
+	return encoder.Encode(makeAtomFeed(feed, defaultUpdateTime))
+}
+
+func GenerateAtom(feed *Feed, defaultUpdateTime time.Time) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := WriteAtom(feed, defaultUpdateTime, &buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func makeAtomFeed(feed *Feed, defaultUpdateTime time.Time) *atomFeed {
+	atom := &atomFeed{
+		ID:        getAtomFeedID(feed),
+		Title:     atomText{Text: feed.Title},
+		Updated:   formatAtomDate(getAtomUpdateTime(feed, defaultUpdateTime)),
+		Generator: feed.Generator,
+		// Atom requires author for each entry, so the feed-level one is used for entries without author
+		Author: &atomPerson{Name: feed.Title},
+	}
+
+	if feed.Description != "" {
+		atom.Subtitle = &atomText{Text: feed.Description}
+	}
+	if feed.Link != "" {
+		atom.Links = append(atom.Links, atomLink{Rel: "alternate", Type: "text/html", Href: feed.Link})
+	}
+	for _, link := range feed.AtomLinks {
+		atom.Links = append(atom.Links, atomLink{Rel: link.Rel, Type: link.Type, Href: link.Href})
+	}
+	if image := feed.Image; image != nil {
+		atom.Logo = image.URL
+	}
+	for _, category := range feed.Category {
+		atom.Categories = append(atom.Categories, atomCategory{Term: category})
+	}
+
+	for _, item := range feed.Items {
+		atom.Entries = append(atom.Entries, makeAtomEntry(item, atom.Updated))
+	}
+
+	return atom
+}
+
+func makeAtomEntry(item *Item, feedUpdated string) atomEntry {
+	entry := atomEntry{
+		ID:      getAtomID(item),
+		Title:   atomText{Text: item.Title},
+		Updated: feedUpdated,
+	}
+
+	if !item.Date.IsZero() {
+		entry.Updated = formatAtomDate(item.Date.Time)
+		entry.Published = entry.Updated
+	}
+	if item.Author != "" {
+		entry.Author = &atomPerson{Name: item.Author}
+	}
+
+	if item.Link != "" {
+		entry.Links = append(entry.Links, atomLink{Rel: "alternate", Type: "text/html", Href: item.Link})
+	}
+	if item.Comments != "" {
+		entry.Links = append(entry.Links, atomLink{Rel: "replies", Type: "text/html", Href: item.Comments})
+	}
+	for _, enclosure := range item.Enclosure {
+		entry.Links = append(entry.Links, atomLink{
+			Rel:    "enclosure",
+			Type:   enclosure.Type,
+			Href:   enclosure.URL,
+			Length: enclosure.Length,
+		})
+	}
+
+	// Readers show content instead of summary, so description becomes content when there is no dedicated one
+	if item.Content != "" {
+		entry.Content = &atomText{Type: "html", Text: item.Content}
+		if item.Description != "" {
+			entry.Summary = &atomText{Type: "html", Text: item.Description}
+		}
+	} else if item.Description != "" {
+		entry.Content = &atomText{Type: "html", Text: item.Description}
+	}
+
+	for _, category := range item.Categories {
+		entry.Categories = append(entry.Categories, atomCategory{Term: category})
+	}
+
+	return entry
+}
+
+// Atom requires feed ID, so the feed URL is used when the feed has no link
+func getAtomFeedID(feed *Feed) string {
+	if feed.Link != "" {
+		return makeAtomID(feed.Link)
+	}
+
+	for _, link := range feed.AtomLinks {
+		if link.Rel == LinkRelSelf && link.Href != "" {
+			return makeAtomID(link.Href)
+		}
+	}
+
+	return makeAtomID(feed.Title)
+}
+
+func getAtomID(item *Item) string {
+	id := item.GUID.ID
+	if id == "" {
+		id = item.Link
+	}
+	return makeAtomID(id)
+}
+
+// Atom requires IDs to be IRIs, when RSS GUIDs may be arbitrary strings
+func makeAtomID(id string) string {
+	if parsed, err := url.Parse(id); err == nil && parsed.IsAbs() {
+		return id
+	}
+	return "urn:feedsd:" + url.PathEscape(id)
+}
+
+// The update time must be stable across scrapes of unchanged feeds, so it's derived from the feed contents when possible
+func getAtomUpdateTime(feed *Feed, defaultUpdateTime time.Time) time.Time {
+	if !feed.Date.IsZero() {
+		return feed.Date.Time
+	}
+
+	var updated time.Time
+	for _, item := range feed.Items {
+		if item.Date.After(updated) {
+			updated = item.Date.Time
+		}
+	}
+	if updated.IsZero() {
+		updated = defaultUpdateTime
+	}
+
+	return updated
+}
+
+func formatAtomDate(date time.Time) string {
+	return date.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,100 @@
+package rss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAtom(t *testing.T) {
+	t.Parallel()
+
+	falseValue := false
+
+	feed := &Feed{
+		Title:       "Feed title",
+		Link:        "http://example.com/",
+		Description: "Feed description",
+		Items: []*Item{{
+			Title:       "Item title",
+			GUID:        GUID{ID: "item-id", IsPermaLink: &falseValue},
+			Link:        "http://example.com/item",
+			Description: "Item <b>description</b>",
+			Content:     "Item <b>content</b>",
+			Date:        Date{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+			Author:      "John Doe",
+			Categories:  []string{"News"},
+			Enclosure: []*Enclosure{{
+				URL:    "http://example.com/item.mp3",
+				Type:   "audio/mpeg",
+				Length: 1000,
+			}},
+		}, {
+			Title:       "Item without date",
+			Link:        "http://example.com/other",
+			Description: "Other description",
+		}},
+	}
+	feed.Normalize()
+
+	data, err := GenerateAtom(feed, time.Now())
+	require.NoError(t, err)
+
+	require.Equal(t, heredoc.Doc(`
+        <?xml version="1.0" encoding="UTF-8"?>
+        <feed xmlns="http://www.w3.org/2005/Atom">
+            <id>http://example.com/</id>
+            <title>Feed title</title>
+            <subtitle>Feed description</subtitle>
+            <updated>2025-01-02T03:04:05Z</updated>
+            <author>
+                <name>Feed title</name>
+            </author>
+            <link rel="alternate" type="text/html" href="http://example.com/"></link>
+            <entry>
+                <id>urn:feedsd:item-id</id>
+                <title>Item title</title>
+                <updated>2025-01-02T03:04:05Z</updated>
+                <published>2025-01-02T03:04:05Z</published>
+                <author>
+                    <name>John Doe</name>
+                </author>
+                <link rel="alternate" type="text/html" href="http://example.com/item"></link>
+                <link rel="enclosure" type="audio/mpeg" href="http://example.com/item.mp3" length="1000"></link>
+                <summary type="html">Item &lt;b&gt;description&lt;/b&gt;</summary>
+                <content type="html">Item &lt;b&gt;content&lt;/b&gt;</content>
+                <category term="News"></category>
+            </entry>
+            <entry>
+                <id>http://example.com/other</id>
+                <title>Item without date</title>
+                <updated>2025-01-02T03:04:05Z</updated>
+                <link rel="alternate" type="text/html" href="http://example.com/other"></link>
+                <content type="html">Other description</content>
+            </entry>
+        </feed>`,
+	), string(data))
+}
+
+func TestGenerateAtomWithoutDates(t *testing.T) {
+	t.Parallel()
+
+	feed := &Feed{
+		Title:     "Feed title",
+		AtomLinks: []*AtomLink{{Rel: LinkRelSelf, Type: AtomContentType, Href: "https://feeds.example.com/test.atom"}},
+		Items: []*Item{{
+			Title: "Item title",
+			Link:  "http://example.com/item",
+		}},
+	}
+
+	atom := makeAtomFeed(feed, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	require.Equal(t, "https://feeds.example.com/test.atom", atom.ID)
+	require.Equal(t, "2025-01-02T03:04:05Z", atom.Updated)
+	require.Equal(t, atom.Updated, atom.Entries[0].Updated)
+
+	feed.AtomLinks = nil
+	require.Equal(t, "urn:feedsd:Feed%20title", makeAtomFeed(feed, time.Now()).ID)
+}
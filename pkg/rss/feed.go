@@ -15,16 +15,17 @@ import (
 )
 
 type Feed struct {
-	Title       string   `xml:"title"`
-	Link        string   `xml:"link"`
-	Description string   `xml:"description"`
-	Image       *Image   `xml:"image"`
-	Language    string   `xml:"language,omitempty"`
-	Date        Date     `xml:"pubDate"`
-	Category    []string `xml:"category"`
-	Generator   string   `xml:"generator,omitempty"`
-	TTL         int      `xml:"ttl,omitempty"`
-	Items       []*Item  `xml:"item"`
+	Title       string      `xml:"title"`
+	AtomLinks   []*AtomLink `xml:"http://www.w3.org/2005/Atom link"` // Must precede Link which matches any namespace
+	Link        string      `xml:"link"`
+	Description string      `xml:"description"`
+	Image       *Image      `xml:"image"`
+	Language    string      `xml:"language,omitempty"`
+	Date        Date        `xml:"pubDate"`
+	Category    []string    `xml:"category"`
+	Generator   string      `xml:"generator,omitempty"`
+	TTL         int         `xml:"ttl,omitempty"`
+	Items       []*Item     `xml:"item"`
 }
 
 func NewFeed(title string, link *url.URL) *Feed {
@@ -124,6 +125,18 @@ func (f *Feed) String() string {
 	return fmt.Sprintf("XML generation error: %s. Go representation: %#v", err, f)
 }
 
+const (
+	LinkRelSelf = "self"
+	LinkRelHub  = "hub"
+)
+
+// AtomLink is atom:link element which is used in RSS for self and WebSub hub links
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
 type Image struct {
 	URL    string `xml:"url"`
 	Title  string `xml:"title"`
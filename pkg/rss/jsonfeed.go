@@ -0,0 +1,165 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"time"
+)
+
+const JSONFeedContentType = "application/feed+json"
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Hubs        []jsonFeedHub  `json:"hubs,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title,omitempty"`
+	ContentHTML   string               `json:"content_html"`
+	DatePublished string               `json:"date_published,omitempty"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	Title       string `json:"title,omitempty"`
+	SizeInBytes int    `json:"size_in_bytes,omitempty"`
+}
+
+func WriteJSONFeed(feed *Feed, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(makeJSONFeed(feed))
+}
+
+func GenerateJSONFeed(feed *Feed) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := WriteJSONFeed(feed, &buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func makeJSONFeed(feed *Feed) *jsonFeed {
+	result := &jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		Description: feed.Description,
+		Language:    feed.Language,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+
+	if image := feed.Image; image != nil {
+		result.Icon = image.URL
+	}
+
+	for _, link := range feed.AtomLinks {
+		switch link.Rel {
+		case LinkRelSelf:
+			result.FeedURL = link.Href
+		case LinkRelHub:
+			result.Hubs = append(result.Hubs, jsonFeedHub{Type: "WebSub", URL: link.Href})
+		}
+	}
+
+	for _, item := range feed.Items {
+		result.Items = append(result.Items, makeJSONFeedItem(item))
+	}
+
+	return result
+}
+
+func makeJSONFeedItem(item *Item) jsonFeedItem {
+	result := jsonFeedItem{
+		ID:          item.GUID.ID,
+		URL:         item.Link,
+		Title:       item.Title,
+		ContentHTML: item.Content,
+		Tags:        item.Categories,
+	}
+
+	if result.ID == "" {
+		result.ID = item.Link
+	}
+	if result.ContentHTML == "" {
+		result.ContentHTML = item.Description
+	}
+	if !item.Date.IsZero() {
+		result.DatePublished = item.Date.UTC().Format(time.RFC3339)
+	}
+	if item.Author != "" {
+		result.Authors = []jsonFeedAuthor{{Name: item.Author}}
+	}
+
+	for _, enclosure := range item.Enclosure {
+		result.Attachments = append(result.Attachments, jsonFeedAttachment{
+			URL:         enclosure.URL,
+			MimeType:    getAttachmentMimeType(enclosure.Type, ""),
+			SizeInBytes: enclosure.Length,
+		})
+	}
+
+	media := slices.Clone(item.MediaContent)
+	for _, group := range item.MediaGroup {
+		if group.Content != nil {
+			media = append(media, group.Content)
+		}
+	}
+
+	for _, content := range media {
+		if content.URL == "" {
+			continue
+		}
+
+		attachment := jsonFeedAttachment{
+			URL:      content.URL,
+			MimeType: getAttachmentMimeType(content.Type, content.Medium),
+		}
+		if title := content.Title; title != nil {
+			attachment.Title = title.Text
+		}
+
+		result.Attachments = append(result.Attachments, attachment)
+	}
+
+	return result
+}
+
+// MIME type is required for JSON Feed attachments, but it's optional in RSS
+func getAttachmentMimeType(mimeType string, medium string) string {
+	switch {
+	case mimeType != "":
+		return mimeType
+	case medium == "image" || medium == "audio" || medium == "video":
+		return medium + "/*"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,64 @@
+package rss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJSONFeed(t *testing.T) {
+	t.Parallel()
+
+	feed := &Feed{
+		Title: "Feed title",
+		Link:  "http://example.com/",
+		Items: []*Item{{
+			Title:       "Item title",
+			Link:        "http://example.com/item",
+			Description: "Item <b>description</b>",
+			Date:        Date{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+			Categories:  []string{"News"},
+			Enclosure: []*Enclosure{{
+				URL:    "http://example.com/item.mp3",
+				Type:   "audio/mpeg",
+				Length: 1000,
+			}},
+			MediaContent: []*MediaContent{{
+				URL:    "http://example.com/image.jpg",
+				Medium: "image",
+				Title:  &MediaDescription{Text: "Image title"},
+			}},
+		}},
+	}
+	feed.Normalize()
+
+	data, err := GenerateJSONFeed(feed)
+	require.NoError(t, err)
+
+	require.JSONEq(t, heredoc.Doc(`
+        {
+            "version": "https://jsonfeed.org/version/1.1",
+            "title": "Feed title",
+            "home_page_url": "http://example.com/",
+            "items": [{
+                "id": "http://example.com/item",
+                "url": "http://example.com/item",
+                "title": "Item title",
+                "content_html": "Item <b>description</b>",
+                "date_published": "2025-01-02T03:04:05Z",
+                "tags": ["News"],
+                "attachments": [{
+                    "url": "http://example.com/item.mp3",
+                    "mime_type": "audio/mpeg",
+                    "size_in_bytes": 1000
+                }, {
+                    "url": "http://example.com/image.jpg",
+                    "mime_type": "image/*",
+                    "title": "Image title"
+                }]
+            }]
+        }`,
+	), string(data))
+}
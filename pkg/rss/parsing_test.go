@@ -123,6 +123,31 @@ func TestParseMalformed(t *testing.T) {
 	))
 }
 
+func TestParseAtomLinks(t *testing.T) {
+	t.Parallel()
+	testParse(t, heredoc.Doc(`
+        <rss xmlns:atom="http://www.w3.org/2005/Atom" version="2.0">
+            <channel>
+                <title>Test feed</title>
+                <link>https://example.com/</link>
+                <atom:link rel="self" type="application/rss+xml" href="https://feeds.example.com/test.rss"/>
+                <atom:link rel="hub" href="https://feeds.example.com/websub"/>
+            </channel>
+        </rss>`,
+	), heredoc.Doc(`
+        <?xml version="1.0" encoding="UTF-8"?>
+        <rss version="2.0">
+            <channel>
+                <title>Test feed</title>
+                <link xmlns="http://www.w3.org/2005/Atom" rel="self" type="application/rss+xml" href="https://feeds.example.com/test.rss"></link>
+                <link xmlns="http://www.w3.org/2005/Atom" rel="hub" href="https://feeds.example.com/websub"></link>
+                <link>https://example.com/</link>
+                <description></description>
+            </channel>
+        </rss>`,
+	))
+}
+
 func TestReadRss091WithCustomEncoding(t *testing.T) {
 	t.Parallel()
 
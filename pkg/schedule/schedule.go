@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type Schedule interface {
+	// Next returns the next scrape time after the specified one
+	Next(now time.Time) time.Time
+}
+
+type interval struct {
+	period time.Duration
+}
+
+func Every(period time.Duration) Schedule {
+	if period <= 0 {
+		panic(fmt.Sprintf("Invalid schedule period: %s", period))
+	}
+	return interval{period: period}
+}
+
+func (s interval) Next(now time.Time) time.Time {
+	return now.Add(s.period)
+}
+
+type cronSchedule struct {
+	schedule cron.Schedule
+}
+
+// Cron parses a standard five-field cron expression (with optional CRON_TZ= or TZ= prefix) or one of the predefined
+// descriptors like @daily or @every 1h.
+func Cron(spec string) (Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	return cronSchedule{schedule: schedule}, nil
+}
+
+func MustCron(spec string) Schedule {
+	schedule, err := Cron(spec)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+func (s cronSchedule) Next(now time.Time) time.Time {
+	return s.schedule.Next(now)
+}
+
+// Window is a time of day range. To may be less than From which means that the window ends on the next day.
+type Window struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ParseWindow parses windows in "HH:MM-HH:MM" format.
+func ParseWindow(value string) (Window, error) {
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid time window: %q", value)
+	}
+
+	var window Window
+	for _, bound := range []struct {
+		value  string
+		result *time.Duration
+	}{
+		{from, &window.From},
+		{to, &window.To},
+	} {
+		timeOfDay, err := time.Parse("15:04", strings.TrimSpace(bound.value))
+		if err != nil {
+			return Window{}, fmt.Errorf("invalid time window: %q", value)
+		}
+		*bound.result = time.Duration(timeOfDay.Hour())*time.Hour + time.Duration(timeOfDay.Minute())*time.Minute
+	}
+
+	if window.From == window.To {
+		return Window{}, fmt.Errorf("invalid time window: %q", value)
+	}
+
+	return window, nil
+}
+
+func MustParseWindow(value string) Window {
+	window, err := ParseWindow(value)
+	if err != nil {
+		panic(err)
+	}
+	return window
+}
+
+type windows struct {
+	period   time.Duration
+	location *time.Location
+	windows  []Window
+}
+
+// Windows returns a schedule which scrapes the feed with the specified period, but only within the specified time of day
+// windows. Nil location means local time.
+func Windows(period time.Duration, location *time.Location, timeWindows ...Window) Schedule {
+	if period <= 0 {
+		panic(fmt.Sprintf("Invalid schedule period: %s", period))
+	} else if len(timeWindows) == 0 {
+		panic("No time windows are specified")
+	}
+
+	if location == nil {
+		location = time.Local
+	}
+
+	return windows{
+		period:   period,
+		location: location,
+		windows:  timeWindows,
+	}
+}
+
+func (s windows) Next(now time.Time) time.Time {
+	next := now.Add(s.period).In(s.location)
+
+	var (
+		nearest  time.Time
+		midnight = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, s.location)
+	)
+
+	for _, window := range s.windows {
+		// Check the window which might have started yesterday and the windows starting today and tomorrow
+		for day := -1; day <= 1; day++ {
+			start := midnight.AddDate(0, 0, day).Add(window.From)
+
+			end := midnight.AddDate(0, 0, day).Add(window.To)
+			if window.To < window.From {
+				end = end.AddDate(0, 0, 1)
+			}
+
+			if !next.Before(start) && next.Before(end) {
+				return next
+			} else if start.After(next) && (nearest.IsZero() || start.Before(nearest)) {
+				nearest = start
+			}
+		}
+	}
+
+	return nearest
+}
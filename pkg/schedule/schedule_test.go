@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 9, 12, 10, 30, 0, 0, time.UTC)
+	require.Equal(t, now.Add(15*time.Minute), Every(15*time.Minute).Next(now))
+}
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 9, 12, 10, 30, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 9, 13, 9, 0, 0, 0, time.UTC), MustCron("0 9 * * *").Next(now))
+	require.Equal(t, time.Date(2025, 9, 12, 10, 45, 0, 0, time.UTC), MustCron("*/15 * * * *").Next(now))
+
+	_, err := Cron("0 9 * *")
+	require.Error(t, err)
+}
+
+func TestParseWindow(t *testing.T) {
+	t.Parallel()
+
+	window, err := ParseWindow("08:00-23:30")
+	require.NoError(t, err)
+	require.Equal(t, Window{From: 8 * time.Hour, To: 23*time.Hour + 30*time.Minute}, window)
+
+	for _, value := range []string{"08:00", "08:00-08:00", "8-23", "25:00-26:00"} {
+		_, err := ParseWindow(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	t.Parallel()
+
+	location, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	schedule := Windows(time.Hour, location, MustParseWindow("08:00-12:00"), MustParseWindow("22:00-02:00"))
+
+	for _, testCase := range []struct {
+		now  time.Time
+		next time.Time
+	}{
+		{
+			now:  time.Date(2025, 9, 12, 8, 30, 0, 0, location),
+			next: time.Date(2025, 9, 12, 9, 30, 0, 0, location),
+		},
+		{
+			now:  time.Date(2025, 9, 12, 11, 30, 0, 0, location),
+			next: time.Date(2025, 9, 12, 22, 0, 0, 0, location),
+		},
+		{
+			now:  time.Date(2025, 9, 12, 23, 30, 0, 0, location),
+			next: time.Date(2025, 9, 13, 0, 30, 0, 0, location),
+		},
+		{
+			now:  time.Date(2025, 9, 13, 1, 30, 0, 0, location),
+			next: time.Date(2025, 9, 13, 8, 0, 0, 0, location),
+		},
+	} {
+		require.True(t, testCase.next.Equal(schedule.Next(testCase.now)), "%s -> %s", testCase.now, schedule.Next(testCase.now))
+	}
+	// Nil location means local time
+	local := Windows(time.Hour, nil, MustParseWindow("08:00-12:00"))
+	require.Equal(t, 8, local.Next(time.Date(2025, 9, 12, 13, 0, 0, 0, time.Local)).Hour())
+}
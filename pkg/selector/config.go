@@ -0,0 +1,107 @@
+package selector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+)
+
+// Config describes feeds defined in a YAML (or JSON, which is a subset of YAML) file
+type Config struct {
+	Feeds []FeedConfig `yaml:"feeds"`
+}
+
+type FeedConfig struct {
+	Name  string `yaml:"name"`
+	URL   string `yaml:"url"`
+	Title string `yaml:"title"`
+
+	// Selector of feed items
+	Items string `yaml:"items"`
+
+	// Selectors of item fields relative to the item
+	Fields FieldsConfig `yaml:"fields"`
+
+	// Go time layout of item dates or "russian" for dates like "2 янв. 2006". Dates are optional if not set.
+	DateFormat string `yaml:"date_format"`
+	Timezone   string `yaml:"timezone"`
+
+	// If set, the page is fetched using a browser
+	Browser *BrowserConfig `yaml:"browser"`
+}
+
+type FieldsConfig struct {
+	Title       string `yaml:"title"`
+	Link        string `yaml:"link"`
+	Date        string `yaml:"date"`
+	Description string `yaml:"description"`
+}
+
+type BrowserConfig struct {
+	// For how long to wait after page load
+	Sleep time.Duration `yaml:"sleep"`
+}
+
+// Load reads feed definitions from the specified file
+func Load(path string) ([]feed.Feed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	feeds, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q feeds config: %w", path, err)
+	}
+
+	return feeds, nil
+}
+
+func Parse(data []byte) ([]feed.Feed, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{})
+	feeds := make([]feed.Feed, 0, len(config.Feeds))
+
+	for _, feedConfig := range config.Feeds {
+		if _, ok := names[feedConfig.Name]; ok {
+			return nil, fmt.Errorf("duplicated %q feed", feedConfig.Name)
+		}
+		names[feedConfig.Name] = struct{}{}
+
+		feed, err := New(feedConfig)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, nil
+}
+
+func (c *FeedConfig) validate() error {
+	if c.Name == "" {
+		return errors.New("feed name is not specified")
+	} else if c.URL == "" {
+		return errors.New("feed URL is not specified")
+	} else if c.Items == "" {
+		return errors.New("items selector is not specified")
+	} else if c.Fields.Title == "" {
+		return errors.New("title selector is not specified")
+	} else if c.Fields.Date != "" && c.DateFormat == "" {
+		return errors.New("date format is not specified")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/KonishchevDmitry/feedsd/internal/util"
+	"github.com/KonishchevDmitry/feedsd/pkg/browser"
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+	"github.com/KonishchevDmitry/feedsd/pkg/fetch"
+	"github.com/KonishchevDmitry/feedsd/pkg/parse"
+	"github.com/KonishchevDmitry/feedsd/pkg/query"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+const russianDateFormat = "russian"
+
+type selectorFeed struct {
+	config   FeedConfig
+	url      *url.URL
+	location *time.Location
+	options  []fetch.Option
+}
+
+var _ feed.Feed = &selectorFeed{}
+
+// New creates a feed which items are extracted from an HTML page using CSS selectors
+func New(config FeedConfig) (feed.Feed, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	pageURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s feed URL: %w", config.Name, err)
+	}
+
+	location := time.UTC
+	if config.Timezone != "" {
+		location, err = time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s feed timezone: %w", config.Name, err)
+		}
+	}
+
+	var options []fetch.Option
+	if browserConfig := config.Browser; browserConfig != nil {
+		var queryOptions []browser.QueryOption
+		if browserConfig.Sleep != 0 {
+			queryOptions = append(queryOptions, browser.Sleep(browserConfig.Sleep))
+		}
+		options = append(options, fetch.EmulateBrowser(queryOptions...))
+	}
+
+	return &selectorFeed{
+		config:   config,
+		url:      pageURL,
+		location: location,
+		options:  options,
+	}, nil
+}
+
+func (f *selectorFeed) Name() string {
+	return f.config.Name
+}
+
+func (f *selectorFeed) Get(ctx context.Context) (*rss.Feed, error) {
+	doc, err := fetch.HTML(ctx, f.url, f.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	title := f.config.Title
+	if title == "" {
+		title = query.Text(doc.Find("title").First())
+	}
+	if title == "" {
+		title = f.config.Name
+	}
+
+	feed := rss.NewFeed(title, f.url)
+
+	items, err := query.Many(doc.Selection, "feed items", f.config.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := query.ForEach(items, func(selection *goquery.Selection) error {
+		item, err := f.parseItem(selection)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", query.HTMLOrError(selection), err)
+		}
+		feed.Items = append(feed.Items, item)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (f *selectorFeed) parseItem(selection *goquery.Selection) (*rss.Item, error) {
+	fields := f.config.Fields
+	item := &rss.Item{}
+
+	title, err := f.field(selection, "item title", fields.Title)
+	if err != nil {
+		return nil, err
+	}
+	item.Title = query.Text(title)
+
+	link, err := f.field(selection, "item link", fields.Link)
+	if err != nil {
+		return nil, err
+	}
+	href, ok := link.Attr("href")
+	if !ok || href == "" {
+		return nil, util.WithErrorCategory(errors.New("unable to find item link"), util.ErrorCategorySelectorNotFound)
+	}
+	linkURL, err := url.Resolve(f.url, href)
+	if err != nil {
+		return nil, err
+	}
+	item.Link = linkURL.String()
+
+	if fields.Date != "" {
+		date, err := query.One(selection, "item date", fields.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Date.Time, err = f.parseDate(query.Text(date))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fields.Description != "" {
+		description, ok, err := query.Optional(selection, "item description", fields.Description)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			item.Description, err = f.description(description)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return item, nil
+}
+
+// Unlike query.Description, resolves relative links against the page URL as browsers do
+func (f *selectorFeed) description(selection *goquery.Selection) (string, error) {
+	selection = selection.Clone()
+
+	for _, link := range []struct{ element, attr string }{{"a", "href"}, {"img", "src"}} {
+		if err := query.ForEach(selection.Find(link.element), func(element *goquery.Selection) error {
+			if value, ok := element.Attr(link.attr); ok && value != "" {
+				resolved, err := url.Resolve(f.url, value)
+				if err != nil {
+					return err
+				}
+				element.SetAttr(link.attr, resolved.String())
+			}
+			return nil
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	return query.Description(selection, f.url)
+}
+
+// Returns the item itself if selector is empty
+func (f *selectorFeed) field(selection *goquery.Selection, name string, selector string) (*goquery.Selection, error) {
+	if selector == "" {
+		return selection, nil
+	}
+	return query.One(selection, name, selector)
+}
+
+func (f *selectorFeed) parseDate(value string) (time.Time, error) {
+	if f.config.DateFormat == russianDateFormat {
+		return parse.Date(value)
+	}
+
+	date, err := time.ParseInLocation(f.config.DateFormat, value, f.location)
+	if err != nil {
+		return time.Time{}, util.WithErrorCategory(fmt.Errorf("invalid date: %q", value), util.ErrorCategoryParse)
+	}
+	return date, nil
+}
@@ -0,0 +1,108 @@
+package selector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/pkg/fetch"
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+)
+
+func TestSelectorFeed(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(writer, `<html><head><title>Test site</title></head><body>
+			<div class="news">
+				<a href="/first"><h2>First</h2></a>
+				<span class="date">2025-01-02</span>
+				<p class="summary">First <a href="/details">summary</a></p>
+			</div>
+			<div class="news">
+				<a href="https://example.com/second"><h2>Second</h2></a>
+				<span class="date">2025-01-01</span>
+			</div>
+			<div class="news">
+				<a href="/news.php?id=3"><h2>Third</h2></a>
+				<span class="date">2025-01-01</span>
+				<p class="summary"><a href="item/3">Details</a></p>
+			</div>
+			<div class="news">
+				<a href="item/4"><h2>Fourth</h2></a>
+				<span class="date">2025-01-01</span>
+			</div>
+			<div class="news">
+				<a href="../archive/5"><h2>Fifth</h2></a>
+				<span class="date">2025-01-01</span>
+			</div>
+		</body></html>`)
+	}))
+	defer server.Close()
+
+	feeds, err := Parse(fmt.Appendf(nil, `
+feeds:
+  - name: test
+    url: %s/news/list
+    items: .news
+    fields:
+      title: h2
+      link: "a:has(h2)"
+      date: .date
+      description: .summary
+    date_format: "2006-01-02"
+    timezone: Europe/Moscow
+`, server.URL))
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	require.Equal(t, "test", feeds[0].Name())
+
+	ctx := fetch.WithContext(testutil.Context(t), prometheus.NewHistogram(prometheus.HistogramOpts{}))
+	feed, err := feeds[0].Get(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, "Test site", feed.Title)
+	require.Len(t, feed.Items, 5)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	first := feed.Items[0]
+	require.Equal(t, "First", first.Title)
+	require.Equal(t, server.URL+"/first", first.Link)
+	require.True(t, time.Date(2025, 1, 2, 0, 0, 0, 0, moscow).Equal(first.Date.Time))
+	require.Contains(t, first.Description, server.URL+"/details")
+
+	second := feed.Items[1]
+	require.Equal(t, "https://example.com/second", second.Link)
+	require.Empty(t, second.Description)
+
+	third := feed.Items[2]
+	require.Equal(t, server.URL+"/news.php?id=3", third.Link)
+	require.Contains(t, third.Description, server.URL+"/news/item/3")
+
+	require.Equal(t, server.URL+"/news/item/4", feed.Items[3].Link)
+	require.Equal(t, server.URL+"/archive/5", feed.Items[4].Link)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, config := range []string{
+		"feeds: [{name: test}]",
+		"feeds: [{name: test, url: 'https://example.com/', items: div, fields: {title: h2}, unknown: value}]",
+		"feeds: [{name: test, url: 'https://example.com/', items: div, fields: {title: h2, date: span}}]",
+		`feeds:
+  - {name: test, url: 'https://example.com/', items: div, fields: {title: h2}}
+  - {name: test, url: 'https://example.com/', items: div, fields: {title: h2}}`,
+	} {
+		_, err := Parse([]byte(config))
+		require.Error(t, err, config)
+	}
+}
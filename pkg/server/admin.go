@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+
+	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+)
+
+type feedStatusResponse struct {
+	Name         string `json:"name"`
+	Parametrized bool   `json:"parametrized"`
+
+	LastScrape time.Time `json:"last_scrape,omitzero"`
+	Duration   float64   `json:"duration,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Items      int       `json:"items"`
+
+	LastSuccess  time.Time `json:"last_success,omitzero"`
+	LastFailure  time.Time `json:"last_failure,omitzero"`
+	LastError    string    `json:"last_error,omitempty"`
+	FailingSince time.Time `json:"failing_since,omitzero"`
+	ErrorsInARow int       `json:"errors_in_a_row,omitempty"`
+}
+
+func makeFeedStatusResponse(status scraper.FeedStatus) feedStatusResponse {
+	return feedStatusResponse{
+		Name:         status.Name,
+		Parametrized: status.Parametrized,
+
+		LastScrape: status.LastScrape,
+		Duration:   status.Duration.Seconds(),
+		Status:     status.Status,
+		Items:      status.Items,
+
+		LastSuccess:  status.LastSuccess,
+		LastFailure:  status.LastFailure,
+		LastError:    status.LastError,
+		FailingSince: status.FailingSince,
+		ErrorsInARow: status.ErrorsInARow,
+	}
+}
+
+func (s *Server) registerAdminHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/feeds", func(writer http.ResponseWriter, request *http.Request) {
+		var response struct {
+			Feeds []feedStatusResponse `json:"feeds"`
+		}
+
+		response.Feeds = []feedStatusResponse{}
+		for _, status := range s.scrapers.Statuses() {
+			response.Feeds = append(response.Feeds, makeFeedStatusResponse(status))
+		}
+
+		writeJSON(writer, request, http.StatusOK, response)
+	})
+
+	mux.HandleFunc("POST /api/feeds/{name}/refresh", func(writer http.ResponseWriter, request *http.Request) {
+		if s.refreshFeed(writer, request) {
+			writer.WriteHeader(http.StatusAccepted)
+		}
+	})
+}
+
+func (s *Server) refreshFeed(writer http.ResponseWriter, request *http.Request) bool {
+	name := request.PathValue("name")
+
+	if err := s.scrapers.Refresh(name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, scraper.ErrUnknownFeed) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, scraper.ErrNotBackgroundFeed) {
+			status = http.StatusConflict
+		}
+		http.Error(writer, err.Error(), status)
+		return false
+	}
+
+	logging.L(request.Context()).Infof("Forced %s feed refresh has been requested.", name)
+	return true
+}
+
+func writeJSON(writer http.ResponseWriter, request *http.Request, status int, value any) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		logging.L(request.Context()).Errorf("Failed to encode %s response: %s.", request.URL.Path, err)
+		http.Error(writer, "Failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_, _ = writer.Write(append(data, '\n'))
+}
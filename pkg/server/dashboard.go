@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+
+	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+)
+
+const (
+	dashboardPath       = "/status"
+	dashboardChartWidth = 120
+	dashboardBarHeight  = 20
+)
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ago":      formatAgo,
+	"duration": formatDuration,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>feedsd status</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.feed { border: 1px solid #ccc; border-radius: 6px; padding: 0.5em 0.8em; margin-bottom: 0.8em; }
+.feed h2 { font-size: 1.1em; margin: 0 0 0.4em 0; }
+.badge { display: inline-block; padding: 0.1em 0.5em; border-radius: 4px; color: #fff; font-size: 0.8em; }
+.ok { background: #2e7d32; }
+.failing { background: #c62828; }
+.unknown { background: #757575; }
+.errors { color: #c62828; font-size: 0.9em; word-break: break-word; }
+.muted { color: #757575; font-size: 0.9em; }
+svg rect { fill: #1976d2; }
+form { display: inline; }
+</style>
+</head>
+<body>
+<h1>Feeds</h1>
+{{range .}}
+<div class="feed">
+	<h2>{{.Name}} <span class="badge {{.Health}}">{{.Health}}</span>{{if .Parametrized}} <span class="muted">parametrized</span>{{end}}</h2>
+	<div>Last scrape: {{ago .LastScrape}}{{if .Status}} ({{.Status}}, {{duration .Duration}}){{end}}</div>
+	<div>Last success: {{ago .LastSuccess}}{{if .Items}}, {{.Items}} items{{end}}</div>
+	<div>Last failure: {{ago .LastFailure}}{{if .ErrorsInARow}}, {{.ErrorsInARow}} in a row{{end}}</div>
+	{{if .Chart}}<svg width="{{.ChartWidth}}" height="{{.ChartHeight}}" aria-label="Scrape duration history">{{range .Chart}}<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"><title>{{duration .Duration}}</title></rect>{{end}}</svg>{{end}}
+	{{if .RecentErrors}}<ul class="errors">{{range .RecentErrors}}<li>{{ago .Time}}: {{.Error}}</li>{{end}}</ul>{{end}}
+	{{if not .Parametrized}}<form method="post" action="{{.RefreshPath}}"><button type="submit">Refresh now</button></form>{{end}}
+</div>
+{{else}}
+<p class="muted">No feeds are registered.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type dashboardFeed struct {
+	scraper.FeedStatus
+	Health      string
+	RefreshPath string
+	Chart       []dashboardBar
+	ChartWidth  int
+	ChartHeight int
+}
+
+type dashboardBar struct {
+	X, Y, Width, Height int
+	Duration            time.Duration
+}
+
+func makeDashboardFeed(status scraper.FeedStatus) dashboardFeed {
+	feed := dashboardFeed{
+		FeedStatus:  status,
+		Health:      "ok",
+		RefreshPath: fmt.Sprintf("%s/%s/refresh", dashboardPath, status.Name),
+		ChartWidth:  dashboardChartWidth,
+		ChartHeight: dashboardBarHeight,
+	}
+
+	if status.LastScrape.IsZero() {
+		feed.Health = "unknown"
+	} else if status.ErrorsInARow != 0 {
+		feed.Health = "failing"
+	}
+
+	var maxDuration time.Duration
+	for _, duration := range status.Durations {
+		maxDuration = max(maxDuration, duration)
+	}
+
+	if count := len(status.Durations); count != 0 && maxDuration != 0 {
+		width := dashboardChartWidth / count
+		for index, duration := range status.Durations {
+			height := max(1, int(int64(dashboardBarHeight)*int64(duration)/int64(maxDuration)))
+			feed.Chart = append(feed.Chart, dashboardBar{
+				X:        index * width,
+				Y:        dashboardBarHeight - height,
+				Width:    max(1, width-1),
+				Height:   height,
+				Duration: duration,
+			})
+		}
+	}
+
+	return feed
+}
+
+func (s *Server) registerDashboardHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+dashboardPath, func(writer http.ResponseWriter, request *http.Request) {
+		var feeds []dashboardFeed
+		for _, status := range s.scrapers.Statuses() {
+			feeds = append(feeds, makeDashboardFeed(status))
+		}
+
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.Header().Set("Cache-Control", "no-store")
+
+		if err := dashboardTemplate.Execute(writer, feeds); err != nil {
+			logging.L(request.Context()).Errorf("Failed to render the dashboard: %s.", err)
+		}
+	})
+
+	mux.HandleFunc("POST "+dashboardPath+"/{name}/refresh", func(writer http.ResponseWriter, request *http.Request) {
+		if s.refreshFeed(writer, request) {
+			http.Redirect(writer, request, dashboardPath, http.StatusSeeOther)
+		}
+	})
+}
+
+func formatAgo(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return formatDuration(time.Since(t)) + " ago"
+}
+
+func formatDuration(duration time.Duration) string {
+	switch {
+	case duration < time.Second:
+		return duration.Round(time.Millisecond).String()
+	case duration < time.Hour:
+		return duration.Round(time.Second).String()
+	default:
+		return duration.Round(time.Minute).String()
+	}
+}
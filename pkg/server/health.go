@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+func (s *Server) registerHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(writer http.ResponseWriter, request *http.Request) {
+		writeHealth(writer, nil)
+	})
+
+	mux.HandleFunc("GET /readyz", func(writer http.ResponseWriter, request *http.Request) {
+		writeHealth(writer, s.checkReadiness())
+	})
+}
+
+// Returns a list of reasons why the server is not ready
+func (s *Server) checkReadiness() []string {
+	if !s.ready.Load() {
+		return []string{"the server is starting"}
+	}
+
+	var problems []string
+
+	if s.readiness.waitForFeeds {
+		if pending := s.scrapers.Pending(); len(pending) != 0 {
+			problems = append(problems, fmt.Sprintf("feeds without result: %s", strings.Join(pending, ", ")))
+		}
+	}
+
+	if maxFailingFeeds, ok := s.readiness.maxFailingFeeds.Get(); ok {
+		var failing []string
+		for _, status := range s.scrapers.Statuses() {
+			if !status.Parametrized && status.ErrorsInARow != 0 {
+				failing = append(failing, status.Name)
+			}
+		}
+		if len(failing) > maxFailingFeeds {
+			problems = append(problems, fmt.Sprintf(
+				"%d feeds are failing (max %d): %s", len(failing), maxFailingFeeds, strings.Join(failing, ", ")))
+		}
+	}
+
+	return problems
+}
+
+func writeHealth(writer http.ResponseWriter, problems []string) {
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+
+	if len(problems) != 0 {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		for _, problem := range problems {
+			_, _ = fmt.Fprintln(writer, problem)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintln(writer, "ok")
+}
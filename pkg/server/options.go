@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+)
+
+type options struct {
+	scraper   scraper.Config
+	readiness readinessConfig
+	webSub    mo.Option[webSubConfig]
+}
+
+type readinessConfig struct {
+	waitForFeeds    bool
+	maxFailingFeeds mo.Option[int]
+}
+
+func getOptions(opts []Option) options {
+	o := options{
+		scraper: scraper.DefaultConfig(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type Option func(o *options)
+
+// ScrapeConcurrency sets the maximum number of concurrent scrapes of all background and parametrized feeds.
+func ScrapeConcurrency(concurrency int) Option {
+	return func(o *options) {
+		o.scraper.Concurrency = concurrency
+	}
+}
+
+// StaleMaxAge sets for how long the last successfully scraped background feed is served when the following scrapes
+// fail. Zero value disables serving of stale feeds.
+func StaleMaxAge(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.scraper.StaleMaxAge = maxAge
+	}
+}
+
+// RetryPolicy sets how temporary scrape failures of background feeds are retried: the first retry happens after
+// initialDelay, each following one doubles the delay up to maxDelay. Zero maxAttempts disables the retries.
+func RetryPolicy(maxAttempts int, initialDelay time.Duration, maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.scraper.Retry = scraper.RetryPolicy{
+			MaxAttempts:  maxAttempts,
+			InitialDelay: initialDelay,
+			MaxDelay:     maxDelay,
+		}
+	}
+}
+
+// FailureNotice enables serving of the last successfully scraped feed with an additional item describing the failure
+// when a background feed is failing for longer than the specified duration.
+func FailureNotice(after time.Duration) Option {
+	return func(o *options) {
+		o.scraper.FailureNotice = mo.Some(after)
+	}
+}
+
+// ParametrizedCacheTTL sets for how long parametrized feed scrape results are cached. Zero value disables the caching.
+func ParametrizedCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.scraper.ParametrizedCacheTTL = ttl
+	}
+}
+
+// StateDir sets a directory where the daemon persists its state (scraped feeds, etc.) to survive restarts.
+func StateDir(path string) Option {
+	return func(o *options) {
+		o.scraper.StateDir = mo.Some(path)
+	}
+}
+
+// ReadyAfterFirstScrape makes the server report readiness only after every background feed has produced its first
+// result.
+func ReadyAfterFirstScrape() Option {
+	return func(o *options) {
+		o.readiness.waitForFeeds = true
+	}
+}
+
+// MaxFailingFeeds makes the server report unreadiness when more than the specified number of background feeds are
+// failing.
+func MaxFailingFeeds(count int) Option {
+	return func(o *options) {
+		o.readiness.maxFailingFeeds = mo.Some(count)
+	}
+}
+
+// WebSub enables WebSub publishing of background feeds via the specified external hub. baseURL is the public URL of the
+// feeds server which is used to build topic URLs of the feeds.
+func WebSub(baseURL *url.URL, hub *url.URL) Option {
+	return func(o *options) {
+		o.webSub = mo.Some(webSubConfig{baseURL: baseURL, hub: mo.Some(hub)})
+	}
+}
+
+// WebSubHub enables WebSub publishing of background feeds via the built-in hub which is served at /websub path of the
+// feeds server. baseURL is the public URL of the feeds server which is used to build topic URLs of the feeds.
+func WebSubHub(baseURL *url.URL) Option {
+	return func(o *options) {
+		o.webSub = mo.Some(webSubConfig{baseURL: baseURL})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ggicci/httpin"
+	"github.com/gorilla/mux"
+
+	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+	"github.com/KonishchevDmitry/feedsd/pkg/feed"
+)
+
+// Scrape scrapes the registered feed once without starting the server. Parameters of parametrized feeds are passed in
+// the same form as in feed URL. It's intended for debugging.
+func (s *Server) Scrape(ctx context.Context, name string, params url.Values) (scraper.ScrapeResult, error) {
+	s.lock.Lock()
+	route, ok := s.routes[name]
+	s.lock.Unlock()
+
+	if !ok {
+		return scraper.ScrapeResult{}, scraper.ErrUnknownFeed
+	}
+
+	return route.scrape(ctx, params)
+}
+
+func scrapeOnce(ctx context.Context, scrapedFeed feed.Feed, params url.Values) (scraper.ScrapeResult, error) {
+	if len(params) != 0 {
+		return scraper.ScrapeResult{}, errors.New("the feed doesn't accept any parameters")
+	}
+	return scraper.ScrapeOnce(ctx, scrapedFeed), nil
+}
+
+func scrapeParametrizedOnce[P feed.Params](
+	ctx context.Context, scrapedFeed feed.ParametrizedFeed[P], params url.Values,
+) (scraper.ScrapeResult, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "/?"+params.Encode(), nil)
+	if err != nil {
+		return scraper.ScrapeResult{}, err
+	}
+
+	// Path parameters may be passed in the same way as query parameters
+	vars := make(map[string]string, len(params))
+	for name := range params {
+		vars[name] = params.Get(name)
+	}
+	request = mux.SetURLVars(request, vars)
+
+	decoded, err := httpin.Decode[P](request)
+	if err != nil {
+		return scraper.ScrapeResult{}, fmt.Errorf("invalid feed parameters: %w", err)
+	}
+
+	return scraper.ScrapeOnce(ctx, feed.BindParams(scrapedFeed, *decoded)), nil
+}
@@ -5,10 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	logging "github.com/KonishchevDmitry/go-easy-logging"
 	"github.com/ggicci/httpin"
@@ -16,9 +21,12 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"golang.org/x/sync/semaphore"
+	_ "github.com/prometheus/client_golang/prometheus/promhttp/zstd" // Enables zstd compression of metrics
+	"github.com/samber/mo"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+	"github.com/KonishchevDmitry/feedsd/internal/websub"
 	"github.com/KonishchevDmitry/feedsd/pkg/feed"
 )
 
@@ -26,75 +34,202 @@ func init() {
 	integration.UseGorillaMux("path", mux.Vars)
 }
 
+const shutdownTimeout = 10 * time.Second
+
 type Server struct {
-	router   *mux.Router
-	scrapers *scraper.Registry
+	router    atomic.Pointer[mux.Router]
+	scrapers  *scraper.Registry
+	readiness readinessConfig
+	ready     atomic.Bool
+	hub       mo.Option[*websub.Hub]
+
+	lock   sync.Mutex
+	routes map[string]route
+}
+
+type handlerFunc func(ctx context.Context, writer http.ResponseWriter, request *http.Request)
+
+type route struct {
+	handlers map[string]handlerFunc
+	scrape   func(ctx context.Context, params url.Values) (scraper.ScrapeResult, error)
 }
 
-func New() *Server {
+func New(opts ...Option) *Server {
+	options := getOptions(opts)
+
 	s := &Server{
-		router:   mux.NewRouter(),
-		scrapers: scraper.NewRegistry(),
+		readiness: options.readiness,
+		routes:    make(map[string]route),
 	}
-	s.register("/", func(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
-		http.NotFound(writer, request)
-	})
+	if config, ok := options.webSub.Get(); ok {
+		options.scraper.WebSub = mo.Some(s.configureWebSub(config))
+	}
+	s.scrapers = scraper.NewRegistry(options.scraper)
+	s.updateRouter()
+
 	return s
 }
 
+// Register registers a new background feed. It may be called both before and after Serve.
 func (s *Server) Register(feed feed.Feed) error {
-	scraper, err := s.scrapers.Add(feed)
-	if err != nil {
-		return err
-	}
+	return s.setFeed(context.Background(), feed.Name(), func() (route, func(context.Context), error) {
+		feedScraper, err := s.scrapers.Add(feed)
+		if err != nil {
+			return route{}, nil, err
+		}
+		return makeFeedRoute(feed, feedScraper), noRetire, nil
+	})
+}
 
-	s.register(fmt.Sprintf("/%s.rss", feed.Name()), func(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
-		result := scraper.Get(ctx)
-		result.Write(writer)
+// Replace registers the background feed or atomically replaces the already registered feed with the same name.
+func (s *Server) Replace(ctx context.Context, feed feed.Feed) error {
+	return s.setFeed(ctx, feed.Name(), func() (route, func(context.Context), error) {
+		feedScraper, retire := s.scrapers.Replace(feed)
+		return makeFeedRoute(feed, feedScraper), retire, nil
 	})
+}
 
-	return nil
+func makeFeedRoute(feed feed.Feed, feedScraper *scraper.BackgroundScraper) route {
+	handlers := make(map[string]handlerFunc, len(scraper.Formats))
+	for _, format := range scraper.Formats {
+		handlers[scraper.FeedPath(feed.Name(), format)] = func(
+			ctx context.Context, writer http.ResponseWriter, request *http.Request,
+		) {
+			result := feedScraper.Get(ctx)
+			result = result.Format(negotiateFormat(format, writer, request))
+			result.Write(writer, request)
+		}
+	}
+
+	return route{
+		handlers: handlers,
+		scrape: func(ctx context.Context, params url.Values) (scraper.ScrapeResult, error) {
+			return scrapeOnce(ctx, feed, params)
+		},
+	}
 }
 
+// RegisterParametrized registers a new parametrized feed. It may be called both before and after Serve.
 func RegisterParametrized[P feed.Params](s *Server, feed feed.ParametrizedFeed[P]) error {
-	var path string
+	return s.setFeed(context.Background(), feed.Name(), func() (route, func(context.Context), error) {
+		feedScraper, err := scraper.AddParametrized(s.scrapers, feed)
+		if err != nil {
+			return route{}, nil, err
+		}
+		return makeParametrizedFeedRoute(feed, feedScraper), noRetire, nil
+	})
+}
+
+// ReplaceParametrized registers the parametrized feed or atomically replaces the already registered feed with the same
+// name.
+func ReplaceParametrized[P feed.Params](ctx context.Context, s *Server, feed feed.ParametrizedFeed[P]) error {
+	return s.setFeed(ctx, feed.Name(), func() (route, func(context.Context), error) {
+		feedScraper, retire := scraper.ReplaceParametrized(s.scrapers, feed)
+		return makeParametrizedFeedRoute(feed, feedScraper), retire, nil
+	})
+}
+
+func makeParametrizedFeedRoute[P feed.Params](
+	feed feed.ParametrizedFeed[P], feedScraper *scraper.SimpleParametrizedScraper[P],
+) route {
+	makeHandler := func(format scraper.Format) handlerFunc {
+		return func(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
+			params, err := httpin.Decode[P](request)
+			if err != nil {
+				logging.L(ctx).Warnf("Invalid feed parameters: %s.", err)
+				http.NotFound(writer, request)
+				return
+			}
+
+			result := feedScraper.Scrape(ctx, *params)
+			result = result.Format(negotiateFormat(format, writer, request))
+			result.Write(writer, request)
+		}
+	}
+
+	// Feeds with custom paths have a single route, so the format may be selected only via Accept header
+	handlers := make(map[string]handlerFunc)
 	if subPath, ok := feed.Path(); ok {
-		path = fmt.Sprintf("/%s/%s", feed.Name(), strings.TrimPrefix(subPath, "/"))
+		handlers[fmt.Sprintf("/%s/%s", feed.Name(), strings.TrimPrefix(subPath, "/"))] = makeHandler(scraper.FormatRSS)
 	} else {
-		path = fmt.Sprintf("/%s.rss", feed.Name())
+		for _, format := range scraper.Formats {
+			handlers[scraper.FeedPath(feed.Name(), format)] = makeHandler(format)
+		}
+	}
+
+	return route{
+		handlers: handlers,
+		scrape: func(ctx context.Context, params url.Values) (scraper.ScrapeResult, error) {
+			return scrapeParametrizedOnce(ctx, feed, params)
+		},
 	}
+}
 
-	concurrencyLimiter := semaphore.NewWeighted(2)
+// The canonical RSS routes also serve the other formats when the client explicitly asks for them via Accept header
+func negotiateFormat(format scraper.Format, writer http.ResponseWriter, request *http.Request) scraper.Format {
+	if format != scraper.FormatRSS {
+		return format
+	}
+	writer.Header().Add("Vary", "Accept")
+	return scraper.NegotiateFormat(request.Header.Get("Accept"))
+}
 
-	scraper, err := scraper.AddParametrized(s.scrapers, feed)
+// Unregister removes the feed and stops its scraper
+func (s *Server) Unregister(ctx context.Context, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.routes[name]; ok {
+		delete(s.routes, name)
+		s.updateRouter()
+	}
+
+	return s.scrapers.Remove(ctx, name)
+}
+
+func noRetire(context.Context) {}
+
+// Registers the feed created by add(). When the feed replaces an already registered one, the old feed serves requests
+// until the new one is ready and is stopped by the returned retire function after the route switch.
+func (s *Server) setFeed(
+	ctx context.Context, name string, add func() (_ route, retire func(context.Context), _ error),
+) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	route, retire, err := add()
 	if err != nil {
 		return err
 	}
 
-	s.register(path, func(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
-		params, err := httpin.Decode[P](request)
-		if err != nil {
-			logging.L(ctx).Warnf("Invalid feed parameters: %s.", err)
-			http.NotFound(writer, request)
-			return
-		}
+	s.routes[name] = route
+	s.updateRouter()
+	retire(ctx)
 
-		if !concurrencyLimiter.TryAcquire(1) {
-			logging.L(ctx).Debugf("Delaying %s scraping due to concurrency limit...", feed.Name())
-			if err := concurrencyLimiter.Acquire(ctx, 1); err != nil {
-				logging.L(ctx).Debugf("Cancelling %s scraping: %s.", feed.Name(), err)
-				return
-			}
-		}
-		defer concurrencyLimiter.Release(1)
+	return nil
+}
 
-		result := scraper.Scrape(ctx, *params)
-		result.Write(writer)
+// Must be called under the lock. The router is immutable, so it's rebuilt and swapped on each change.
+func (s *Server) updateRouter() {
+	router := mux.NewRouter()
+	register(router, "/", func(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
+		http.NotFound(writer, request)
 	})
+	if hub, ok := s.hub.Get(); ok {
+		router.Handle(webSubHubPath, hub).Methods(http.MethodPost)
+	}
 
-	return nil
+	for _, name := range slices.Sorted(maps.Keys(s.routes)) {
+		route := s.routes[name]
+		for _, path := range slices.Sorted(maps.Keys(route.handlers)) {
+			register(router, path, route.handlers[path])
+		}
+	}
+
+	s.router.Store(router)
 }
 
+// Serve starts the feed scrapers and serves the feeds until the context is cancelled or any of the HTTP servers fails
 func (s *Server) Serve(ctx context.Context, feedsAddr string, metricsAddr string, develMode bool) error {
 	var waitGroup sync.WaitGroup
 	defer waitGroup.Wait()
@@ -103,17 +238,23 @@ func (s *Server) Serve(ctx context.Context, feedsAddr string, metricsAddr string
 		return err
 	}
 
+	// Feeds may be registered and removed at runtime, so always use the current router. Incoming trace context is
+	// propagated to the scrapes triggered by the requests.
+	feedsHandler := otelhttp.NewHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		s.router.Load().ServeHTTP(writer, request)
+	}), "feeds")
+
 	//nolint:gosec
 	feedsServer := http.Server{
 		Addr:     feedsAddr,
-		Handler:  s.router,
+		Handler:  feedsHandler,
 		ErrorLog: log.New(newHTTPLogger(logging.L(ctx)), "Feeds HTTP server: ", 0),
 		BaseContext: func(net.Listener) context.Context {
 			return ctx
 		},
 	}
 	defer func() {
-		if err := feedsServer.Shutdown(ctx); err != nil {
+		if err := shutdownServer(ctx, &feedsServer); err != nil {
 			logging.L(ctx).Errorf("Failed to shutdown feeds HTTP server: %s.", err)
 		}
 	}()
@@ -122,6 +263,9 @@ func (s *Server) Serve(ctx context.Context, feedsAddr string, metricsAddr string
 	metricsMux.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
 		ErrorLog: newPrometheusLogger(logging.L(ctx)),
 	}))
+	s.registerAdminHandlers(metricsMux)
+	s.registerDashboardHandlers(metricsMux)
+	s.registerHealthHandlers(metricsMux)
 
 	//nolint:gosec
 	metricsServer := http.Server{
@@ -130,7 +274,7 @@ func (s *Server) Serve(ctx context.Context, feedsAddr string, metricsAddr string
 		ErrorLog: log.New(newHTTPLogger(logging.L(ctx)), "Metrics HTTP server: ", 0),
 	}
 	defer func() {
-		if err := metricsServer.Shutdown(ctx); err != nil {
+		if err := shutdownServer(ctx, &metricsServer); err != nil {
 			logging.L(ctx).Errorf("Failed to shutdown metrics HTTP server: %s.", err)
 		}
 	}()
@@ -179,17 +323,36 @@ func (s *Server) Serve(ctx context.Context, feedsAddr string, metricsAddr string
 		}
 	})
 
-	s.scrapers.Start(ctx, develMode)
+	if err := s.scrapers.Start(ctx, develMode); err != nil {
+		return err
+	}
 	defer s.scrapers.Stop(ctx)
 
-	return <-serverCrashed
+	s.ready.Store(true)
+	defer s.ready.Store(false)
+
+	select {
+	case err := <-serverCrashed:
+		return err
+	case <-ctx.Done():
+		logging.L(ctx).Infof("Shutting down...")
+		return nil
+	}
+}
+
+// Gracefully shuts down the server. The context may be already cancelled at this point, so it's not used for the
+// shutdown deadline.
+func shutdownServer(ctx context.Context, server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(ctx)
 }
 
-func (s *Server) register(path string, handler func(ctx context.Context, writer http.ResponseWriter, request *http.Request)) {
-	s.router.HandleFunc(path, func(writer http.ResponseWriter, request *http.Request) {
+func register(router *mux.Router, path string, handler handlerFunc) {
+	router.HandleFunc(path, func(writer http.ResponseWriter, request *http.Request) {
 		ctx := request.Context()
 		logging.L(ctx).Debugf("%s %s...", request.Method, request.RequestURI)
 		handler(ctx, writer, request)
 		logging.L(ctx).Debugf("%s %s finished.", request.Method, request.RequestURI)
-	})
+	}).Methods(http.MethodGet, http.MethodHead)
 }
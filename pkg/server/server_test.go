@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+	"github.com/KonishchevDmitry/feedsd/pkg/rss"
+	"github.com/KonishchevDmitry/feedsd/pkg/test/testutil"
+	"github.com/KonishchevDmitry/feedsd/pkg/url"
+)
+
+func TestRuntimeRegistration(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	server := New()
+
+	require.NoError(t, server.scrapers.Start(ctx, false))
+	defer server.scrapers.Stop(ctx)
+
+	require.NoError(t, server.Register(&testFeed{name: "test", title: "Original"}))
+	require.Error(t, server.Register(&testFeed{name: "test", title: "Duplicate"}))
+
+	status, body := get(ctx, server, "/test.rss")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "Original")
+
+	// The new feed must serve the old content until it's scraped
+	require.NoError(t, server.Replace(ctx, &testFeed{name: "test", title: "Replaced"}))
+	status, body = get(ctx, server, "/test.rss")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "Original")
+
+	require.NoError(t, server.scrapers.Refresh("test"))
+	require.Eventually(t, func() bool {
+		_, body := get(ctx, server, "/test.rss")
+		return strings.Contains(body, "Replaced")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, server.Unregister(ctx, "test"))
+	status, _ = get(ctx, server, "/test.rss")
+	require.Equal(t, http.StatusNotFound, status)
+	require.ErrorIs(t, server.Unregister(ctx, "test"), scraper.ErrUnknownFeed)
+
+	// The name may be reused after the removal
+	require.NoError(t, server.Register(&testFeed{name: "test", title: "Registered again"}))
+	status, body = get(ctx, server, "/test.rss")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "Registered again")
+}
+
+func TestUnregisterCancelsScrape(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t)
+	server := New()
+
+	require.NoError(t, server.scrapers.Start(ctx, false))
+	defer server.scrapers.Stop(ctx)
+
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	require.NoError(t, server.Register(&testFeed{name: "test", get: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}}))
+	require.NoError(t, server.scrapers.Refresh("test"))
+	<-started
+
+	unregistered := make(chan error, 1)
+	go func() {
+		unregistered <- server.Unregister(ctx, "test")
+	}()
+
+	select {
+	case err := <-unregistered:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Unregister has stuck on the in-flight scrape")
+	}
+	<-cancelled
+}
+
+func get(ctx context.Context, server *Server, path string) (int, string) {
+	recorder := httptest.NewRecorder()
+	server.router.Load().ServeHTTP(recorder, httptest.NewRequestWithContext(ctx, http.MethodGet, path, nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+type testFeed struct {
+	name  string
+	title string
+	get   func(ctx context.Context) error
+}
+
+func (f *testFeed) Name() string {
+	return f.name
+}
+
+func (f *testFeed) Get(ctx context.Context) (*rss.Feed, error) {
+	if f.get != nil {
+		if err := f.get(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	feed := rss.NewFeed(f.title, url.MustParse("https://example.com/"))
+	feed.AddItem(time.Now(), "Test item", url.MustParse("https://example.com/item"), "")
+	return feed, nil
+}
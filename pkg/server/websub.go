@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/samber/mo"
+
+	"github.com/KonishchevDmitry/feedsd/internal/scraper"
+	"github.com/KonishchevDmitry/feedsd/internal/websub"
+)
+
+const webSubHubPath = "/websub"
+
+type webSubConfig struct {
+	baseURL *url.URL
+	// The built-in hub is used when the external one isn't specified
+	hub mo.Option[*url.URL]
+}
+
+func (s *Server) configureWebSub(config webSubConfig) scraper.WebSubConfig {
+	if hubURL, ok := config.hub.Get(); ok {
+		return scraper.WebSubConfig{
+			BaseURL:   config.baseURL,
+			Hub:       hubURL,
+			Publisher: websub.NewNotifier(hubURL),
+		}
+	}
+
+	hubURL := config.baseURL.JoinPath(webSubHubPath)
+	hub := websub.NewHub(hubURL, config.baseURL)
+	s.hub = mo.Some(hub)
+
+	return scraper.WebSubConfig{
+		BaseURL:   config.baseURL,
+		Hub:       hubURL,
+		Publisher: hub,
+	}
+}
@@ -0,0 +1,90 @@
+// Package tracing configures OpenTelemetry tracing of feed scraping. Spans are always created, but they are recorded
+// and exported only when tracing is configured.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	logging "github.com/KonishchevDmitry/go-easy-logging"
+	"github.com/samber/mo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+type options struct {
+	endpoint    mo.Option[string]
+	serviceName string
+}
+
+type Option func(o *options)
+
+// Endpoint sets OTLP/HTTP collector URL (http://localhost:4318 for example). By default, the standard
+// OTEL_EXPORTER_OTLP_* environment variables are used.
+func Endpoint(url string) Option {
+	return func(o *options) {
+		o.endpoint = mo.Some(url)
+	}
+}
+
+// ServiceName overrides the default service name ("feedsd").
+func ServiceName(name string) Option {
+	return func(o *options) {
+		o.serviceName = name
+	}
+}
+
+// Configure sets up the global tracer provider which exports spans to OTLP collector and enables trace context
+// propagation. The returned function flushes the pending spans and stops the exporter.
+func Configure(ctx context.Context, opts ...Option) (func(), error) {
+	options := options{serviceName: "feedsd"}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var exporterOptions []otlptracehttp.Option
+	if endpoint, ok := options.endpoint.Get(); ok {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithEndpointURL(endpoint))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override our defaults
+	traceResource, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(options.serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	// Sampling may be configured via the standard OTEL_TRACES_SAMPLER* environment variables
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(traceResource),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		if err := provider.Shutdown(ctx); err != nil {
+			logging.L(ctx).Errorf("Failed to shutdown the tracer provider: %s.", err)
+		}
+	}, nil
+}
@@ -19,6 +19,8 @@ func MustParse(value string) *url.URL {
 	return url
 }
 
+// Get joins root-relative links onto the base URL and leaves all other links as is. Use Resolve for RFC 3986
+// resolution.
 func Get(base *url.URL, link string) (*url.URL, error) {
 	if strings.HasPrefix(link, "/") {
 		return base.JoinPath(link), nil
@@ -31,3 +33,13 @@ func Get(base *url.URL, link string) (*url.URL, error) {
 
 	return url, nil
 }
+
+// Resolve resolves the link (absolute or relative) against the URL of the page it was found on as browsers do
+// (RFC 3986)
+func Resolve(base *url.URL, link string) (*url.URL, error) {
+	url, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("got an invalid link: %q", link)
+	}
+	return base.ResolveReference(url), nil
+}